@@ -29,7 +29,7 @@ func main() {
 		defer term.Restore(os.Stdin.Fd(), state)
 	}
 
-	rd, err := input.NewDriver(in, os.Getenv("TERM"), 0)
+	rd, err := input.NewDriver(in, input.WithTerminfo(os.Getenv("TERM")))
 	if err != nil {
 		log.Printf("error creating driver: %v\r\n", err)
 		return
@@ -0,0 +1,73 @@
+package input
+
+import "time"
+
+// DefaultClickInterval is the default maximum time between consecutive mouse
+// presses to count them as a multi-click.
+const DefaultClickInterval = 500 * time.Millisecond
+
+// clickTolerance is how far, in cells, the mouse can move between
+// consecutive presses before the click count is reset.
+const clickTolerance = 1
+
+// clickState keeps track of consecutive mouse presses.
+type clickState struct {
+	x, y   int
+	button MouseButton
+	time   time.Time
+	count  int
+}
+
+// SetClickInterval sets the maximum time between consecutive mouse presses to
+// count them as a multi-click. It only has an effect when FlagMouseClicks is
+// set. A zero or negative interval restores DefaultClickInterval.
+func (p *Parser) SetClickInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultClickInterval
+	}
+	p.clickInterval = interval
+}
+
+// mouseClicks fills in the Clicks field of mouse press events. It does
+// nothing unless FlagMouseClicks is set.
+func (p *Parser) mouseClicks(e Event) Event {
+	if p.flags&FlagMouseClicks == 0 {
+		return e
+	}
+
+	c := &p.clicks
+	switch e := e.(type) {
+	case MouseDownEvent:
+		if e.IsWheel() {
+			// Wheel events are not clicks.
+			c.count = 0
+			return e
+		}
+
+		now := p.now()
+		if c.count > 0 && e.Button == c.button && e.X == c.x && e.Y == c.y &&
+			now.Sub(c.time) <= p.clickInterval {
+			c.count++
+		} else {
+			c.count = 1
+		}
+
+		c.x, c.y, c.button, c.time = e.X, e.Y, e.Button, now
+		e.Clicks = c.count
+		return e
+	case MouseMoveEvent:
+		if abs(e.X-c.x) > clickTolerance || abs(e.Y-c.y) > clickTolerance {
+			// The mouse moved away, start over.
+			c.count = 0
+		}
+	}
+
+	return e
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
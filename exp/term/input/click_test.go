@@ -0,0 +1,129 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMouseClicks(t *testing.T) {
+	type press struct {
+		after time.Duration // time since the previous event
+		seq   string
+	}
+
+	cases := []struct {
+		name     string
+		interval time.Duration
+		events   []press
+		want     []Event
+	}{
+		{
+			"single, double, and triple click",
+			0,
+			[]press{
+				{0, "\x1b[<0;5;5M"},
+				{50 * time.Millisecond, "\x1b[<0;5;5m"},
+				{100 * time.Millisecond, "\x1b[<0;5;5M"},
+				{100 * time.Millisecond, "\x1b[<0;5;5M"},
+			},
+			[]Event{
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 1},
+				MouseUpEvent{X: 4, Y: 4, Button: MouseButtonLeft},
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 2},
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 3},
+			},
+		},
+		{
+			"too slow",
+			0,
+			[]press{
+				{0, "\x1b[<0;5;5M"},
+				{600 * time.Millisecond, "\x1b[<0;5;5M"},
+			},
+			[]Event{
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 1},
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 1},
+			},
+		},
+		{
+			"custom interval",
+			time.Second,
+			[]press{
+				{0, "\x1b[<0;5;5M"},
+				{600 * time.Millisecond, "\x1b[<0;5;5M"},
+			},
+			[]Event{
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 1},
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 2},
+			},
+		},
+		{
+			"different cell and button",
+			0,
+			[]press{
+				{0, "\x1b[<0;5;5M"},
+				{10 * time.Millisecond, "\x1b[<0;6;5M"},
+				{10 * time.Millisecond, "\x1b[<2;6;5M"},
+			},
+			[]Event{
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 1},
+				MouseDownEvent{X: 5, Y: 4, Button: MouseButtonLeft, Clicks: 1},
+				MouseDownEvent{X: 5, Y: 4, Button: MouseButtonRight, Clicks: 1},
+			},
+		},
+		{
+			"small motion between clicks",
+			0,
+			[]press{
+				{0, "\x1b[<0;5;5M"},
+				{10 * time.Millisecond, "\x1b[<35;6;6M"},
+				{10 * time.Millisecond, "\x1b[<0;5;5M"},
+			},
+			[]Event{
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 1},
+				MouseMoveEvent{X: 5, Y: 5},
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 2},
+			},
+		},
+		{
+			"motion beyond the tolerance",
+			0,
+			[]press{
+				{0, "\x1b[<0;5;5M"},
+				{10 * time.Millisecond, "\x1b[<35;8;5M"},
+				{10 * time.Millisecond, "\x1b[<0;5;5M"},
+			},
+			[]Event{
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 1},
+				MouseMoveEvent{X: 7, Y: 4},
+				MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft, Clicks: 1},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := newDriver("", FlagMouseClicks)
+			d.SetClickInterval(c.interval)
+
+			now := time.Unix(0, 0)
+			d.parser.now = func() time.Time { return now }
+
+			var got []Event
+			for _, p := range c.events {
+				now = now.Add(p.after)
+				got = append(got, d.parser.decode([]byte(p.seq))...)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %#v, got %#v", c.want, got)
+			}
+		})
+	}
+
+	// Clicks are not counted without the flag.
+	want := []Event{MouseDownEvent{X: 4, Y: 4, Button: MouseButtonLeft}}
+	if got := DecodeString("\x1b[<0;5;5M"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
@@ -0,0 +1,60 @@
+package input
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// ClipboardEvent represents a clipboard report event. This is the terminal
+// response to a clipboard read request (OSC 52) i.e.
+// OSC 52 ; Pc ; <base64> ST.
+//
+// Selection is the first clipboard name in the reply, usually
+// ansi.SystemClipboard or ansi.PrimaryClipboard, and zero if the terminal
+// didn't report one. An empty Content means the clipboard is empty.
+//
+// Some terminals and multiplexers echo a read request back unanswered when
+// clipboard access is denied. In that case, Query is true and Content is
+// empty.
+type ClipboardEvent struct {
+	Selection byte
+	Content   string
+	Query     bool
+}
+
+// String implements fmt.Stringer.
+func (e ClipboardEvent) String() string {
+	return EventString(e)
+}
+
+// parseOscClipboard parses the payload of an OSC 52 sequence i.e. Pc ; Pd.
+// It returns nil if the payload is malformed.
+func parseOscClipboard(_ int, payload string) Event {
+	sel, data, ok := strings.Cut(payload, ";")
+	if !ok {
+		return nil
+	}
+
+	var e ClipboardEvent
+	if len(sel) > 0 {
+		e.Selection = sel[0]
+	}
+
+	// The query marker "?" isn't valid base64, don't try to decode it.
+	if data == "?" {
+		e.Query = true
+		return e
+	}
+
+	// Some terminals omit the base64 padding.
+	enc := base64.StdEncoding
+	if len(data)%4 != 0 {
+		enc = base64.RawStdEncoding
+	}
+	b, err := enc.DecodeString(data)
+	if err != nil {
+		return nil
+	}
+	e.Content = string(b)
+	return e
+}
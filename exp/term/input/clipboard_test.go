@@ -0,0 +1,51 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/x/exp/term/ansi"
+)
+
+func TestParseClipboardEvent(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		{"\x1b]52;c;aGVsbG8gd29ybGQ=\x07", ClipboardEvent{Selection: 'c', Content: "hello world"}},
+		{"\x1b]52;p;aGk=\x1b\\", ClipboardEvent{Selection: 'p', Content: "hi"}},
+		{"\x1b]52;;aGk=\x07", ClipboardEvent{Content: "hi"}},
+		{"\x1b]52;c;\x07", ClipboardEvent{Selection: 'c'}},
+		{"\x1b]52;c;?\x07", ClipboardEvent{Selection: 'c', Query: true}},
+		{"\x1b]52;c;aGVsbG8gd29ybGQ\x07", ClipboardEvent{Selection: 'c', Content: "hello world"}},
+		{"\x9d52;s0;aGk=\x9c", ClipboardEvent{Selection: 's', Content: "hi"}},
+		{"\x1b]52;c;aGk==\x07", UnknownOscEvent("\x1b]52;c;aGk==\x07")},
+		{"\x1b]52;c;a\x07", UnknownOscEvent("\x1b]52;c;a\x07")},
+		{"\x1b]52;c;not base64!\x07", UnknownOscEvent("\x1b]52;c;not base64!\x07")},
+		{"\x1b]52;c\x07", UnknownOscEvent("\x1b]52;c\x07")},
+	}
+
+	for _, c := range cases {
+		_, e := ParseSequence([]byte(c.seq))
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+}
+
+func TestClipboardEventRoundTrip(t *testing.T) {
+	for _, s := range []string{"", "hello", "multi\nline ✓"} {
+		seq := ansi.SetClipboard(ansi.SystemClipboard, s)
+		_, e := ParseSequence([]byte(seq))
+		want := ClipboardEvent{Selection: ansi.SystemClipboard, Content: s}
+		if !reflect.DeepEqual(e, want) {
+			t.Errorf("%q: expected %#v, got %#v", seq, want, e)
+		}
+	}
+
+	seq := ansi.RequestClipboard(ansi.PrimaryClipboard)
+	_, e := ParseSequence([]byte(seq))
+	if want := (ClipboardEvent{Selection: ansi.PrimaryClipboard, Query: true}); !reflect.DeepEqual(e, want) {
+		t.Errorf("%q: expected %#v, got %#v", seq, want, e)
+	}
+}
@@ -0,0 +1,76 @@
+package input
+
+import "image/color"
+
+// CloneEvent returns a deep copy of the given event. The copy doesn't share
+// any mutable state with the original i.e. slices, maps, and colors are
+// copied.
+//
+// Events that don't hold references are returned as is.
+func CloneEvent(e Event) Event {
+	switch e := e.(type) {
+	case MultiEvent:
+		if e == nil {
+			return e
+		}
+		events := make(MultiEvent, len(e))
+		for i, ev := range e {
+			events[i] = CloneEvent(ev)
+		}
+		return events
+	case ForegroundColorEvent:
+		return ForegroundColorEvent{cloneColor(e.Color)}
+	case BackgroundColorEvent:
+		return BackgroundColorEvent{cloneColor(e.Color)}
+	case CursorColorEvent:
+		return CursorColorEvent{cloneColor(e.Color)}
+	case PrimaryDeviceAttributesEvent:
+		if e == nil {
+			return e
+		}
+		return append(PrimaryDeviceAttributesEvent{}, e...)
+	case SecondaryDeviceAttributesEvent:
+		if e == nil {
+			return e
+		}
+		return append(SecondaryDeviceAttributesEvent{}, e...)
+	case DcsDataEvent:
+		return DcsDataEvent{
+			Params:        cloneBytes(e.Params),
+			Intermediates: cloneBytes(e.Intermediates),
+			Final:         e.Final,
+			Data:          cloneBytes(e.Data),
+		}
+	case TermcapEvent:
+		tc := TermcapEvent{IsValid: e.IsValid}
+		if e.Values != nil {
+			tc.Values = make(map[string]string, len(e.Values))
+			for k, v := range e.Values {
+				tc.Values[k] = v
+			}
+		}
+		return tc
+	}
+
+	return e
+}
+
+// cloneColor snapshots a color. Colors from the standard library are values
+// and are returned as is, other colors are converted to color.RGBA since they
+// might reference mutable state.
+func cloneColor(c color.Color) color.Color {
+	switch c.(type) {
+	case nil:
+		return nil
+	case color.RGBA, color.NRGBA, color.RGBA64, color.NRGBA64, color.Gray, color.Gray16, color.Alpha, color.Alpha16:
+		return c
+	}
+	return color.RGBAModel.Convert(c)
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
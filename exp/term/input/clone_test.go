@@ -0,0 +1,66 @@
+package input
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+// mutableColor is a color that can be changed after creating an event.
+type mutableColor struct{ c color.RGBA }
+
+func (m *mutableColor) RGBA() (r, g, b, a uint32) { return m.c.RGBA() }
+
+func TestCloneEvent(t *testing.T) {
+	m := MultiEvent{KeyDownEvent{Rune: 'a'}, PrimaryDeviceAttributesEvent{62, 22}}
+	c := CloneEvent(m).(MultiEvent)
+	if !reflect.DeepEqual(c, m) {
+		t.Fatalf("expected %v, got %v", m, c)
+	}
+	c[0] = KeyDownEvent{Rune: 'b'}
+	c[1].(PrimaryDeviceAttributesEvent)[0] = 1
+	if want := (MultiEvent{KeyDownEvent{Rune: 'a'}, PrimaryDeviceAttributesEvent{62, 22}}); !reflect.DeepEqual(m, want) {
+		t.Errorf("mutating the clone changed the original: %v", m)
+	}
+
+	mc := &mutableColor{color.RGBA{R: 0xff, A: 0xff}}
+	fg := ForegroundColorEvent{mc}
+	cfg := CloneEvent(fg).(ForegroundColorEvent)
+	mc.c = color.RGBA{B: 0xff, A: 0xff}
+	if want := (color.RGBA{R: 0xff, A: 0xff}); cfg.Color != want {
+		t.Errorf("expected the clone color to be %v, got %v", want, cfg.Color)
+	}
+
+	nc := color.NRGBA{R: 0xff, A: 0x80}
+	if bg := CloneEvent(BackgroundColorEvent{nc}).(BackgroundColorEvent); bg.Color != nc {
+		t.Errorf("expected %v, got %v", nc, bg.Color)
+	}
+
+	dcs := DcsDataEvent{Params: []byte("1"), Final: 'q', Data: []byte("abc")}
+	cdcs := CloneEvent(dcs).(DcsDataEvent)
+	cdcs.Data[0] = 'x'
+	cdcs.Params[0] = '2'
+	if string(dcs.Data) != "abc" || string(dcs.Params) != "1" {
+		t.Errorf("mutating the clone changed the original: %v", dcs)
+	}
+
+	tc := TermcapEvent{Values: map[string]string{"Tc": ""}, IsValid: true}
+	ctc := CloneEvent(tc).(TermcapEvent)
+	ctc.Values["RGB"] = "8"
+	if len(tc.Values) != 1 {
+		t.Errorf("mutating the clone changed the original: %v", tc)
+	}
+
+	da2 := SecondaryDeviceAttributesEvent{41, 351, 0}
+	cda2 := CloneEvent(da2).(SecondaryDeviceAttributesEvent)
+	cda2[1] = 0
+	if da2.Version() != 351 {
+		t.Errorf("mutating the clone changed the original: %v", da2)
+	}
+
+	for _, e := range []Event{KeyDownEvent{Rune: 'a'}, PasteEvent("abc"), UnknownCsiEvent("\x1b[z"), nil} {
+		if c := CloneEvent(e); !reflect.DeepEqual(c, e) {
+			t.Errorf("expected %v, got %v", e, c)
+		}
+	}
+}
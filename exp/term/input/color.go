@@ -1,10 +1,13 @@
 package input
 
 import (
+	_ "embed"
 	"fmt"
 	"image/color"
+	"math"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // ForegroundColorEvent represents a foreground color change event.
@@ -12,7 +15,7 @@ type ForegroundColorEvent struct{ color.Color }
 
 // String implements fmt.Stringer.
 func (e ForegroundColorEvent) String() string {
-	return colorToHex(e)
+	return EventString(e)
 }
 
 // BackgroundColorEvent represents a background color change event.
@@ -20,7 +23,38 @@ type BackgroundColorEvent struct{ color.Color }
 
 // String implements fmt.Stringer.
 func (e BackgroundColorEvent) String() string {
-	return colorToHex(e)
+	return EventString(e)
+}
+
+// IsDark returns whether the background color is dark. A color is dark when
+// white text has a better contrast ratio on it than black text, that is when
+// its relative luminance is below ~0.179. An unset color is considered dark
+// like most terminal backgrounds.
+//
+// See: https://www.w3.org/TR/WCAG21/#dfn-relative-luminance
+func (e BackgroundColorEvent) IsDark() bool {
+	if e.Color == nil {
+		return true
+	}
+	return relativeLuminance(e.Color) < darkLuminance
+}
+
+// darkLuminance is the relative luminance where black and white text have the
+// same contrast ratio i.e. (L + 0.05) / 0.05 = 1.05 / (L + 0.05).
+var darkLuminance = math.Sqrt(1.05*0.05) - 0.05
+
+// relativeLuminance returns the WCAG relative luminance of the color, from 0
+// for black to 1 for white. The alpha channel is ignored.
+func relativeLuminance(c color.Color) float64 {
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	linear := func(v uint8) float64 {
+		s := float64(v) / 0xff
+		if s <= 0.04045 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(nc.R) + 0.7152*linear(nc.G) + 0.0722*linear(nc.B)
 }
 
 // CursorColorEvent represents a cursor color change event.
@@ -28,42 +62,246 @@ type CursorColorEvent struct{ color.Color }
 
 // String implements fmt.Stringer.
 func (e CursorColorEvent) String() string {
-	return colorToHex(e)
+	return EventString(e)
+}
+
+// PaletteColorEvent represents a palette color report event. This is the
+// terminal response to a palette color query (OSC 4) i.e.
+// OSC 4 ; <index> ; rgb:.../.../... ST.
+type PaletteColorEvent struct {
+	Index int
+	color.Color
+}
+
+// String implements fmt.Stringer.
+func (e PaletteColorEvent) String() string {
+	return EventString(e)
+}
+
+// parseOscPalette parses one or more palette color reports. Terminals can
+// report multiple colors in a single reply as index;value pairs i.e.
+// OSC 4 ; 1 ; rgb:.../.../... ; 2 ; rgb:.../.../... ST. It returns nil if
+// the reply or any of its colors is malformed.
+func parseOscPalette(_ int, payload string) Event {
+	parts := strings.Split(payload, ";")
+	if len(parts)%2 != 0 {
+		return nil
+	}
+
+	events := make([]Event, 0, len(parts)/2)
+	for i := 0; i < len(parts); i += 2 {
+		idx, err := strconv.ParseUint(parts[i], 10, 16)
+		if err != nil {
+			return nil
+		}
+		c := xParseColor(parts[i+1])
+		if c == nil {
+			return nil
+		}
+		events = append(events, PaletteColorEvent{Index: int(idx), Color: c})
+	}
+
+	if len(events) == 1 {
+		return events[0]
+	}
+	return MultiEvent(events)
+}
+
+// parseOscColors parses one or more dynamic color reports. Terminals can
+// batch multiple colors in a single reply either as code;value groups i.e.
+// OSC 10;rgb:.../.../...;11;rgb:.../.../... ST or as consecutive values where
+// each value belongs to the next color code i.e. OSC 10;<fg>;<bg> ST.
+//
+// It returns nil if the reply or any of its colors is malformed. Unsupported
+// color codes are skipped.
+func parseOscColors(code int, payload string) Event {
+	var events []Event
+	parts := strings.Split(payload, ";")
+	for i := 0; i < len(parts); i++ {
+		if i > 0 {
+			if n, err := strconv.Atoi(parts[i]); err == nil {
+				// A new code;value group
+				code = n
+				i++
+				if i >= len(parts) {
+					return nil
+				}
+			} else {
+				code++
+			}
+		}
+
+		if parts[i] == "" {
+			return nil
+		}
+
+		c := xParseColor(parts[i])
+		if c == nil {
+			return nil
+		}
+
+		switch code {
+		case 10:
+			events = append(events, ForegroundColorEvent{c})
+		case 11:
+			events = append(events, BackgroundColorEvent{c})
+		case 12:
+			events = append(events, CursorColorEvent{c})
+		}
+	}
+
+	switch len(events) {
+	case 0:
+		return nil
+	case 1:
+		return events[0]
+	default:
+		return MultiEvent(events)
+	}
 }
 
+// colorToHex returns the hex representation of the color. Colors that are
+// not fully opaque are rendered as #rrggbbaa.
 func colorToHex(c color.Color) string {
-	r, g, b, _ := c.RGBA()
-	r >>= 8
-	g >>= 8
-	b >>= 8
-	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nc.A != 0xff {
+		return fmt.Sprintf("#%02x%02x%02x%02x", nc.R, nc.G, nc.B, nc.A)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", nc.R, nc.G, nc.B)
 }
 
+// xParseColor parses an XParseColor color specification i.e. rgb:r/g/b or
+// rgba:r/g/b/a where each component is 1 to 4 hex digits, #rgb, #rrggbb,
+// #rrrgggbbb, or #rrrrggggbbbb, or an X11 color name e.g. "RosyBrown". It
+// returns nil if the specification is malformed or the name is unknown.
 func xParseColor(s string) color.Color {
+	var parts []string
 	switch {
 	case strings.HasPrefix(s, "rgb:"):
-		parts := strings.Split(s[4:], "/")
+		parts = strings.Split(s[4:], "/")
 		if len(parts) != 3 {
-			return color.Black
+			return nil
 		}
-
-		r, _ := strconv.ParseUint(parts[0], 16, 32)
-		g, _ := strconv.ParseUint(parts[1], 16, 32)
-		b, _ := strconv.ParseUint(parts[2], 16, 32)
-
-		return color.RGBA{uint8(r), uint8(g), uint8(b), 255}
 	case strings.HasPrefix(s, "rgba:"):
-		parts := strings.Split(s[5:], "/")
+		parts = strings.Split(s[5:], "/")
 		if len(parts) != 4 {
-			return color.Black
+			return nil
+		}
+	case strings.HasPrefix(s, "#"):
+		return parseHexColor(s[1:])
+	default:
+		return lookupX11Color(s)
+	}
+
+	var c [4]uint8
+	for i, p := range parts {
+		v, ok := scaleHex(p)
+		if !ok {
+			return nil
+		}
+		c[i] = v
+	}
+
+	if len(parts) == 3 {
+		return color.RGBA{c[0], c[1], c[2], 255}
+	}
+	return color.NRGBA{c[0], c[1], c[2], c[3]}
+}
+
+// parseHexColor parses the digits of a #rgb, #rrggbb, #rrrgggbbb, or
+// #rrrrggggbbbb color. Unlike the rgb: form, the digits aren't scaled, they
+// are the most significant bits of each component i.e. #3a7 is the same as
+// #3000a0007000 and #30a070.
+func parseHexColor(s string) color.Color {
+	if len(s) == 0 || len(s) > 12 || len(s)%3 != 0 {
+		return nil
+	}
+
+	n := len(s) / 3
+	var c [3]uint8
+	for i := range c {
+		p := s[i*n : (i+1)*n]
+		v, err := strconv.ParseUint(p, 16, 16)
+		if err != nil {
+			return nil
+		}
+		// Keep the 8 most significant bits.
+		if shift := 4 * (n - 2); shift > 0 {
+			v >>= shift
+		} else if shift < 0 {
+			v <<= -shift
+		}
+		c[i] = uint8(v)
+	}
+
+	return color.RGBA{c[0], c[1], c[2], 255}
+}
+
+// rgbTxt is the X11 color name database i.e. /usr/share/X11/rgb.txt.
+//
+//go:embed rgb.txt
+var rgbTxt string
+
+var (
+	x11ColorsOnce sync.Once
+	x11Colors     map[string]color.RGBA
+)
+
+// lookupX11Color returns the X11 color with the given name. Names are case
+// insensitive. It returns nil if the name is unknown.
+func lookupX11Color(name string) color.Color {
+	x11ColorsOnce.Do(func() {
+		x11Colors = parseRgbTxt(rgbTxt)
+	})
+
+	c, ok := x11Colors[strings.ToLower(name)]
+	if !ok {
+		return nil
+	}
+	return c
+}
+
+// parseRgbTxt parses an X11 rgb.txt color database. Each line is a color in
+// the form <red> <green> <blue> <name>, where the name might have spaces.
+// Lines starting with '!' are comments.
+func parseRgbTxt(s string) map[string]color.RGBA {
+	colors := make(map[string]color.RGBA)
+line:
+	for _, line := range strings.Split(s, "\n") {
+		f := strings.Fields(line)
+		if len(f) < 4 || strings.HasPrefix(f[0], "!") {
+			continue
+		}
+
+		var c [3]uint8
+		for i := range c {
+			v, err := strconv.ParseUint(f[i], 10, 8)
+			if err != nil {
+				continue line
+			}
+			c[i] = uint8(v)
 		}
 
-		r, _ := strconv.ParseUint(parts[0], 16, 32)
-		g, _ := strconv.ParseUint(parts[1], 16, 32)
-		b, _ := strconv.ParseUint(parts[2], 16, 32)
-		a, _ := strconv.ParseUint(parts[3], 16, 32)
+		colors[strings.ToLower(strings.Join(f[3:], " "))] = color.RGBA{c[0], c[1], c[2], 255}
+	}
+	return colors
+}
+
+// scaleHex scales a 1 to 4 digit hex color component to 8 bits. Like
+// XParseColor, the value is first scaled to 16 bits based on the number of
+// digits, then truncated to its 8 most significant bits i.e. "f", "ff", "fff",
+// and "ffff" are all 0xff, and "8", "88", "888", and "8888" are all 0x88. It
+// returns false if s isn't a 1 to 4 digit hex number.
+func scaleHex(s string) (uint8, bool) {
+	if len(s) == 0 || len(s) > 4 {
+		return 0, false
+	}
 
-		return color.RGBA{uint8(r), uint8(g), uint8(b), uint8(a)}
+	v, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		return 0, false
 	}
-	return color.Black
+
+	max := uint64(1)<<(4*len(s)) - 1
+	return uint8((v * 0xffff / max) >> 8), true
 }
@@ -0,0 +1,223 @@
+package input
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestParseColorEvents(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+		str  string
+	}{
+		{"\x1b]12;rgb:ffff/0000/0000\x07", CursorColorEvent{color.RGBA{R: 0xff, A: 0xff}}, "CursorColorEvent{Color: #ff0000}"},
+		{"\x1b]12;rgba:ffff/0000/0000/8000\x07", CursorColorEvent{color.NRGBA{R: 0xff, A: 0x80}}, "CursorColorEvent{Color: #ff000080}"},
+		{"\x1b]12;rgba:ff/00/00/ff\x07", CursorColorEvent{color.NRGBA{R: 0xff, A: 0xff}}, "CursorColorEvent{Color: #ff0000}"},
+		{"\x1b]10;rgb:f/8/0\x1b\\", ForegroundColorEvent{color.RGBA{R: 0xff, G: 0x88, A: 0xff}}, "ForegroundColorEvent{Color: #ff8800}"},
+		{"\x1b]11;rgb:1a1a/1b1b/2626\x07", BackgroundColorEvent{color.RGBA{R: 0x1a, G: 0x1b, B: 0x26, A: 0xff}}, "BackgroundColorEvent{Color: #1a1b26}"},
+		{"\x1b]11;rgb:fff/000/800\x07", BackgroundColorEvent{color.RGBA{R: 0xff, B: 0x80, A: 0xff}}, "BackgroundColorEvent{Color: #ff0080}"},
+	}
+
+	for _, c := range cases {
+		_, e := ParseSequence([]byte(c.seq))
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+			continue
+		}
+		if s, ok := e.(interface{ String() string }); !ok || s.String() != c.str {
+			t.Errorf("%q: expected string %q, got %v", c.seq, c.str, e)
+		}
+	}
+}
+
+func TestParseBatchedColorEvents(t *testing.T) {
+	fg := ForegroundColorEvent{color.RGBA{R: 0xff, A: 0xff}}
+	bg := BackgroundColorEvent{color.RGBA{B: 0xff, A: 0xff}}
+	cur := CursorColorEvent{color.RGBA{G: 0xff, A: 0xff}}
+
+	cases := []struct {
+		name string
+		in   string
+		want []Event
+	}{
+		{"code;value groups", "\x1b]10;rgb:ffff/0000/0000;11;rgb:0000/0000/ffff\x07", []Event{fg, bg}},
+		{"consecutive values", "\x1b]10;rgb:ffff/0000/0000;rgb:0000/0000/ffff;rgb:0000/ffff/0000\x1b\\", []Event{fg, bg, cur}},
+		{"out of order groups", "\x1b]11;rgb:0000/0000/ffff;10;rgb:ffff/0000/0000\x07", []Event{bg, fg}},
+		{"unsupported codes are skipped", "\x1b]10;rgb:ffff/0000/0000;17;rgb:0000/0000/ffff\x07", []Event{fg}},
+		{"separate sequences with mixed terminators", "\x1b]10;rgb:ffff/0000/0000\x07\x1b]11;rgb:0000/0000/ffff\x1b\\", []Event{fg, bg}},
+		{"missing value", "\x1b]10;rgb:ffff/0000/0000;11\x07", []Event{UnknownOscEvent("\x1b]10;rgb:ffff/0000/0000;11\x07")}},
+		{"malformed color", "\x1b]11;rgb:ffff/0000\x07", []Event{UnknownOscEvent("\x1b]11;rgb:ffff/0000\x07")}},
+		{"invalid hex", "\x1b]11;rgb:ffff/00g0/0000\x1b\\", []Event{UnknownOscEvent("\x1b]11;rgb:ffff/00g0/0000\x1b\\")}},
+		{"too many digits", "\x1b]10;rgb:fffff/0/0\x07", []Event{UnknownOscEvent("\x1b]10;rgb:fffff/0/0\x07")}},
+		{"unknown color space", "\x1b]10;cmyk:0/0/0/0\x07", []Event{UnknownOscEvent("\x1b]10;cmyk:0/0/0/0\x07")}},
+		{"one malformed color in a batch", "\x1b]10;rgb:ffff/0000/0000;11;rgb:\x07", []Event{UnknownOscEvent("\x1b]10;rgb:ffff/0000/0000;11;rgb:\x07")}},
+		{"query echo", "\x1b]11;?\x07", []Event{UnknownOscEvent("\x1b]11;?\x07")}},
+		{"empty value", "\x1b]10;rgb:ffff/0000/0000;;\x07", []Event{UnknownOscEvent("\x1b]10;rgb:ffff/0000/0000;;\x07")}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DecodeString(c.in); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestXParseColor(t *testing.T) {
+	cases := []struct {
+		spec string
+		want color.Color
+	}{
+		{"rgb:ffff/8080/0000", color.RGBA{R: 0xff, G: 0x80, A: 0xff}},
+		{"rgba:ff/80/00/80", color.NRGBA{R: 0xff, G: 0x80, A: 0x80}},
+		{"#ff8800", color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+		{"#FF8800", color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+		{"#3a7", color.RGBA{R: 0x30, G: 0xa0, B: 0x70, A: 0xff}},
+		{"#300a00700", color.RGBA{R: 0x30, G: 0xa0, B: 0x70, A: 0xff}},
+		{"#3000a0007000", color.RGBA{R: 0x30, G: 0xa0, B: 0x70, A: 0xff}},
+		{"#ffff88880000", color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+		{"rosybrown", color.RGBA{R: 188, G: 143, B: 143, A: 0xff}},
+		{"RosyBrown", color.RGBA{R: 188, G: 143, B: 143, A: 0xff}},
+		{"rosy brown", color.RGBA{R: 188, G: 143, B: 143, A: 0xff}},
+		{"ROSYBROWN4", color.RGBA{R: 139, G: 105, B: 105, A: 0xff}},
+		{"gray50", color.RGBA{R: 127, G: 127, B: 127, A: 0xff}},
+		{"White", color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+
+		// Malformed
+		{"#", nil},
+		{"#ff88", nil},
+		{"#ff880g", nil},
+		{"#ff8800ff88001", nil},
+		{"notacolor", nil},
+		{"", nil},
+	}
+
+	for _, c := range cases {
+		if got := xParseColor(c.spec); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.spec, c.want, got)
+		}
+	}
+}
+
+func TestParseColorEventsHexAndNames(t *testing.T) {
+	in := "\x1b]10;#ff8800\x07\x1b]11;MidnightBlue\x1b\\"
+	want := []Event{
+		ForegroundColorEvent{color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+		BackgroundColorEvent{color.RGBA{R: 25, G: 25, B: 112, A: 0xff}},
+	}
+	if got := DecodeString(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBackgroundColorEventIsDark(t *testing.T) {
+	cases := []struct {
+		name string
+		c    color.Color
+		dark bool
+	}{
+		{"black", color.Black, true},
+		{"white", color.White, false},
+		{"tokyo night", color.RGBA{R: 0x1a, G: 0x1b, B: 0x26, A: 0xff}, true},
+		{"solarized dark", color.RGBA{G: 0x2b, B: 0x36, A: 0xff}, true},
+		{"solarized light", color.RGBA{R: 0xfd, G: 0xf6, B: 0xe3, A: 0xff}, false},
+		{"pure blue", color.RGBA{B: 0xff, A: 0xff}, true},
+		{"pure yellow", color.RGBA{R: 0xff, G: 0xff, A: 0xff}, false},
+		{"translucent white", color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x80}, false},
+		{"unset", nil, true},
+
+		// The threshold is at a relative luminance of ~0.179 i.e. a gray
+		// between #75 and #76.
+		{"just below the threshold", color.Gray{Y: 0x75}, true},
+		{"just above the threshold", color.Gray{Y: 0x76}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := (BackgroundColorEvent{c.c}).IsDark(); got != c.dark {
+				t.Errorf("expected %v, got %v", c.dark, got)
+			}
+		})
+	}
+}
+
+func TestScaleHex(t *testing.T) {
+	cases := []struct {
+		digits []string
+		want   uint8
+	}{
+		{[]string{"0", "00", "000", "0000"}, 0x00},
+		{[]string{"f", "ff", "fff", "ffff"}, 0xff},
+		{[]string{"F", "FF", "FFF", "FFFF"}, 0xff},
+		{[]string{"8", "88", "888", "8888"}, 0x88},
+		{[]string{"1", "11", "111", "1111"}, 0x11},
+		{[]string{"7", "77", "777", "7777"}, 0x77},
+		{[]string{"80", "800", "8000"}, 0x80},
+		{[]string{"7f", "7ff", "7fff"}, 0x7f},
+		{[]string{"1a", "1a1", "1a1a"}, 0x1a},
+		{[]string{"fe", "fef", "fefe"}, 0xfe},
+		{[]string{"01", "010", "0101"}, 0x01},
+		{[]string{"00f", "00ff"}, 0x00},
+		{[]string{"0100"}, 0x01},
+	}
+
+	for _, c := range cases {
+		for _, d := range c.digits {
+			if got, ok := scaleHex(d); !ok || got != c.want {
+				t.Errorf("%q: expected %#02x, got %#02x (%v)", d, c.want, got, ok)
+			}
+		}
+	}
+
+	for _, d := range []string{"", "fffff", "g", "-1", "+f"} {
+		if _, ok := scaleHex(d); ok {
+			t.Errorf("%q: expected an error", d)
+		}
+	}
+
+	// rgb:f/f/f is white.
+	if got, want := xParseColor("rgb:f/f/f"), color.Color(color.RGBA{0xff, 0xff, 0xff, 0xff}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+	// Components can have different widths.
+	if got, want := xParseColor("rgb:f/80/fff"), color.Color(color.RGBA{0xff, 0x80, 0xff, 0xff}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
+
+func TestParsePaletteColorEvents(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	blue := color.RGBA{B: 0xff, A: 0xff}
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		{"\x1b]4;1;rgb:ffff/0000/0000\x07", PaletteColorEvent{Index: 1, Color: red}},
+		{"\x1b]4;255;rgb:00/00/ff\x1b\\", PaletteColorEvent{Index: 255, Color: blue}},
+		{"\x1b]4;1;rgb:ff/00/00;4;#0000ff\x07", MultiEvent{
+			PaletteColorEvent{Index: 1, Color: red},
+			PaletteColorEvent{Index: 4, Color: blue},
+		}},
+		{"\x1b]4;1;?\x07", UnknownOscEvent("\x1b]4;1;?\x07")},
+		{"\x1b]4;1\x07", UnknownOscEvent("\x1b]4;1\x07")},
+		{"\x1b]4;x;rgb:ff/00/00\x07", UnknownOscEvent("\x1b]4;x;rgb:ff/00/00\x07")},
+		{"\x1b]4;1;rgb:ff/00/00;2\x07", UnknownOscEvent("\x1b]4;1;rgb:ff/00/00;2\x07")},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+
+	if got, want := (PaletteColorEvent{Index: 1, Color: red}).String(), "PaletteColorEvent{Index: 1, Color: #ff0000}"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
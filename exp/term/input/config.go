@@ -0,0 +1,79 @@
+package input
+
+import "fmt"
+
+// Config represents the key table options of the driver. Each field mirrors
+// one of the driver flags, see the flag documentation for details.
+type Config struct {
+	// CtrlAt reports NUL (0x00) as ctrl+@ instead of ctrl+space. See
+	// FlagCtrlAt.
+	CtrlAt bool
+
+	// CtrlI reports HT (0x09) as ctrl+i instead of tab. See FlagCtrlI.
+	CtrlI bool
+
+	// CtrlM reports CR (0x0d) as ctrl+m instead of enter. See FlagCtrlM.
+	CtrlM bool
+
+	// CtrlOpenBracket reports ESC (0x1b) as ctrl+[ instead of escape. See
+	// FlagCtrlOpenBracket.
+	CtrlOpenBracket bool
+
+	// Space reports space and ctrl+space as runes instead of key symbols.
+	// See FlagSpace.
+	Space bool
+
+	// Backspace reports DEL (0x7f) as delete instead of backspace. See
+	// FlagBackspace.
+	Backspace bool
+
+	// Find reports the Find key instead of treating it as Home. See
+	// FlagFind.
+	Find bool
+
+	// Select reports the Select key instead of treating it as End. See
+	// FlagSelect.
+	Select bool
+
+	// NoXTerm doesn't register XTerm key sequences. See FlagNoXTerm.
+	NoXTerm bool
+
+	// NoTerminfo doesn't use Terminfo databases to overwrite the default key
+	// sequences. See FlagNoTerminfo.
+	NoTerminfo bool
+}
+
+// Validate returns an error wrapping ErrInvalidConfig if the config has
+// contradictory options. CtrlAt and Space both choose how NUL is reported,
+// ctrl+@ and ctrl+space respectively, and can't be set together.
+func (c Config) Validate() error {
+	if c.CtrlAt && c.Space {
+		return fmt.Errorf("%w: CtrlAt and Space both set the NUL key", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// flags returns the driver flags of the config.
+func (c Config) flags() int {
+	var flags int
+	for _, f := range []struct {
+		set  bool
+		flag int
+	}{
+		{c.CtrlAt, FlagCtrlAt},
+		{c.CtrlI, FlagCtrlI},
+		{c.CtrlM, FlagCtrlM},
+		{c.CtrlOpenBracket, FlagCtrlOpenBracket},
+		{c.Space, FlagSpace},
+		{c.Backspace, FlagBackspace},
+		{c.Find, FlagFind},
+		{c.Select, FlagSelect},
+		{c.NoXTerm, FlagNoXTerm},
+		{c.NoTerminfo, FlagNoTerminfo},
+	} {
+		if f.set {
+			flags |= f.flag
+		}
+	}
+	return flags
+}
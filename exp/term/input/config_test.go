@@ -0,0 +1,130 @@
+package input
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfigFlags(t *testing.T) {
+	cases := []struct {
+		name string
+		c    Config
+		want int
+	}{
+		{"empty", Config{}, 0},
+		{"ctrl@", Config{CtrlAt: true}, FlagCtrlAt},
+		{"ctrl+i", Config{CtrlI: true}, FlagCtrlI},
+		{"ctrl+m", Config{CtrlM: true}, FlagCtrlM},
+		{"ctrl+[", Config{CtrlOpenBracket: true}, FlagCtrlOpenBracket},
+		{"space", Config{Space: true}, FlagSpace},
+		{"backspace", Config{Backspace: true}, FlagBackspace},
+		{"find", Config{Find: true}, FlagFind},
+		{"select", Config{Select: true}, FlagSelect},
+		{"no xterm", Config{NoXTerm: true}, FlagNoXTerm},
+		{"no terminfo", Config{NoTerminfo: true}, FlagNoTerminfo},
+		{"combined", Config{CtrlI: true, Find: true, NoTerminfo: true}, FlagCtrlI | FlagFind | FlagNoTerminfo},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.c.flags(); got != c.want {
+				t.Errorf("expected flags %b, got %b", c.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := (Config{CtrlAt: true, CtrlI: true, Backspace: true}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (Config{CtrlAt: true, Space: true}).Validate(); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+
+	_, err := NewDriver(strings.NewReader(""), WithConfig(Config{CtrlAt: true, Space: true}))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected NewDriver to return ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestConfigTable(t *testing.T) {
+	type entry struct {
+		seq  string
+		want KeyDownEvent
+	}
+	cases := []struct {
+		name    string
+		c       Config
+		entries []entry
+	}{
+		{"default", Config{}, []entry{
+			{"\x00", KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl}},
+			{" ", KeyDownEvent{Sym: KeySpace, Rune: ' '}},
+			{"\t", KeyDownEvent{Sym: KeyTab}},
+			{"\r", KeyDownEvent{Sym: KeyEnter}},
+			{"\x1b", KeyDownEvent{Sym: KeyEscape}},
+			{"\x7f", KeyDownEvent{Sym: KeyBackspace}},
+			{"\x1b[1~", KeyDownEvent{Sym: KeyHome}},
+			{"\x1b[4~", KeyDownEvent{Sym: KeyEnd}},
+			{"\x1b[1;5A", KeyDownEvent{Sym: KeyUp, Mod: Ctrl}},
+		}},
+		{"ctrl@", Config{CtrlAt: true}, []entry{
+			{"\x00", KeyDownEvent{Rune: '@', Mod: Ctrl}},
+			{" ", KeyDownEvent{Sym: KeySpace, Rune: ' '}},
+		}},
+		{"space", Config{Space: true}, []entry{
+			{"\x00", KeyDownEvent{Rune: ' ', Mod: Ctrl}},
+			{" ", KeyDownEvent{Rune: ' '}},
+		}},
+		{"ctrl+i", Config{CtrlI: true}, []entry{
+			{"\t", KeyDownEvent{Rune: 'i', Mod: Ctrl}},
+		}},
+		{"ctrl+m", Config{CtrlM: true}, []entry{
+			{"\r", KeyDownEvent{Rune: 'm', Mod: Ctrl}},
+		}},
+		{"ctrl+[", Config{CtrlOpenBracket: true}, []entry{
+			{"\x1b", KeyDownEvent{Rune: '[', Mod: Ctrl}},
+		}},
+		{"backspace", Config{Backspace: true}, []entry{
+			{"\x7f", KeyDownEvent{Sym: KeyDelete}},
+		}},
+		{"find", Config{Find: true}, []entry{
+			{"\x1b[1~", KeyDownEvent{Sym: KeyFind}},
+		}},
+		{"select", Config{Select: true}, []entry{
+			{"\x1b[4~", KeyDownEvent{Sym: KeySelect}},
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := newDriver("", c.c.flags())
+			for _, e := range c.entries {
+				if got, ok := d.parser.table[e.seq]; !ok || got != e.want {
+					t.Errorf("%q: expected %v, got %v", e.seq, e.want, got)
+				}
+			}
+		})
+	}
+
+	t.Run("no xterm", func(t *testing.T) {
+		d := newDriver("", Config{NoXTerm: true}.flags())
+		if k, ok := d.parser.table["\x1b[1;5A"]; ok {
+			t.Errorf("expected no XTerm sequences, got %v", k)
+		}
+	})
+}
+
+func TestCtrlAtPrecedence(t *testing.T) {
+	// FlagCtrlAt wins over FlagSpace for NUL, while FlagSpace still applies
+	// to the space key.
+	d := newDriver("", FlagCtrlAt|FlagSpace)
+	if k, want := d.parser.table["\x00"], (KeyDownEvent{Rune: '@', Mod: Ctrl}); k != want {
+		t.Errorf("expected NUL to be %v, got %v", want, k)
+	}
+	if k, want := d.parser.table[" "], (KeyDownEvent{Rune: ' '}); k != want {
+		t.Errorf("expected space to be %v, got %v", want, k)
+	}
+}
@@ -0,0 +1,106 @@
+package input
+
+import (
+	"strconv"
+)
+
+// CursorPositionEvent represents a cursor position report event. This is the
+// terminal response to a device status report request for the cursor
+// position (DSR 6 and DECXCPR) i.e. CSI Pl ; Pc R and CSI ? Pl ; Pc [; Pp] R.
+//
+// Row and Col are zero-based unlike the one-based values terminals report.
+type CursorPositionEvent struct {
+	Row int
+	Col int
+}
+
+// String implements fmt.Stringer.
+func (e CursorPositionEvent) String() string {
+	return EventString(e)
+}
+
+// isCursorPosition returns whether the parameters of a CSI R sequence are a
+// cursor position report rather than an F3 key. Terminals report F3 as CSI R,
+// and a modified F3 as CSI <modifiers> R or CSI 1 ; <modifiers> R, while a
+// cursor position report always has a row and a column.
+//
+// The precedence rule is: a sequence with exactly the modified key
+// parameters, i.e. a 1 followed by a modifier in the range the key table
+// registers (2-16) with an optional event kind, is F3. Any other sequence
+// with two parameters is a cursor position report. This means a cursor on
+// the first row and one of the first 16 columns is reported as a key, there's
+// no way to tell these apart.
+func isCursorPosition(params [][]uint) bool {
+	if len(params) != 2 {
+		return false
+	}
+	if len(params[0]) == 1 && params[0][0] == 1 {
+		// CSI 1 ; <modifiers> [: <kind>] R
+		if m := params[1][0]; len(params[1]) > 1 || m >= 2 && m <= xtermModMax {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCursorPosition parses the Pl ; Pc parameters of a cursor position
+// report. Missing or zero values default to one.
+func parseCursorPosition(params [][]uint) CursorPositionEvent {
+	row, col := 1, 1
+	if params[0][0] > 0 {
+		row = int(params[0][0])
+	}
+	if len(params) > 1 && params[1][0] > 0 {
+		col = int(params[1][0])
+	}
+	return CursorPositionEvent{Row: row - 1, Col: col - 1}
+}
+
+// Cursor styles reported by CursorStyleEvent.
+const (
+	CursorBlock = iota
+	CursorUnderline
+	CursorBar
+)
+
+// CursorStyleEvent represents a cursor style report event. This is the
+// terminal response to a DECRQSS request for the cursor style (DECSCUSR) i.e.
+// DCS 1 $ r Ps SP q ST.
+//
+// Style is one of CursorBlock, CursorUnderline, or CursorBar.
+type CursorStyleEvent struct {
+	Style    int
+	Blinking bool
+}
+
+// String implements fmt.Stringer.
+func (e CursorStyleEvent) String() string {
+	return EventString(e)
+}
+
+// parseCursorStyle parses the DECSCUSR Ps value of a cursor style report.
+// Ps 0 and 1 are a blinking block, 2 a steady block, 3 and 4 a blinking and
+// steady underline, and 5 and 6 a blinking and steady bar. An empty Ps is the
+// same as 0.
+func parseCursorStyle(ps []byte) (CursorStyleEvent, bool) {
+	var n int
+	if len(ps) > 0 {
+		var err error
+		n, err = strconv.Atoi(string(ps))
+		if err != nil {
+			return CursorStyleEvent{}, false
+		}
+	}
+
+	switch n {
+	case 0, 1:
+		return CursorStyleEvent{Style: CursorBlock, Blinking: true}, true
+	case 2:
+		return CursorStyleEvent{Style: CursorBlock}, true
+	case 3, 4:
+		return CursorStyleEvent{Style: CursorUnderline, Blinking: n == 3}, true
+	case 5, 6:
+		return CursorStyleEvent{Style: CursorBar, Blinking: n == 5}, true
+	}
+	return CursorStyleEvent{}, false
+}
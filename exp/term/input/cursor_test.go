@@ -0,0 +1,136 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCursorStyleEvent(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+		str  string
+	}{
+		{"\x1bP1$r q\x1b\\", CursorStyleEvent{Style: CursorBlock, Blinking: true}, "CursorStyleEvent{Style: 0, Blinking: true}"},
+		{"\x1bP1$r0 q\x1b\\", CursorStyleEvent{Style: CursorBlock, Blinking: true}, "CursorStyleEvent{Style: 0, Blinking: true}"},
+		{"\x1bP1$r1 q\x1b\\", CursorStyleEvent{Style: CursorBlock, Blinking: true}, "CursorStyleEvent{Style: 0, Blinking: true}"},
+		{"\x1bP1$r2 q\x1b\\", CursorStyleEvent{Style: CursorBlock}, "CursorStyleEvent{Style: 0, Blinking: false}"},
+		{"\x1bP1$r3 q\x1b\\", CursorStyleEvent{Style: CursorUnderline, Blinking: true}, "CursorStyleEvent{Style: 1, Blinking: true}"},
+		{"\x1bP1$r4 q\x1b\\", CursorStyleEvent{Style: CursorUnderline}, "CursorStyleEvent{Style: 1, Blinking: false}"},
+		{"\x1bP1$r5 q\x1b\\", CursorStyleEvent{Style: CursorBar, Blinking: true}, "CursorStyleEvent{Style: 2, Blinking: true}"},
+		{"\x901$r6 q\x9c", CursorStyleEvent{Style: CursorBar}, "CursorStyleEvent{Style: 2, Blinking: false}"},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+			continue
+		}
+		if s := e.(CursorStyleEvent).String(); s != c.str {
+			t.Errorf("%q: expected string %q, got %q", c.seq, c.str, s)
+		}
+	}
+}
+
+func TestParseCursorStyleEventInvalid(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		// The terminal doesn't support the request
+		{"\x1bP0$r\x1b\\", DcsDataEvent{Params: []byte("0"), Intermediates: []byte("$"), Final: 'r'}},
+		// Unknown cursor styles
+		{"\x1bP1$r7 q\x1b\\", DcsDataEvent{Params: []byte("1"), Intermediates: []byte("$"), Final: 'r', Data: []byte("7 q")}},
+		{"\x1bP1$rx q\x1b\\", DcsDataEvent{Params: []byte("1"), Intermediates: []byte("$"), Final: 'r', Data: []byte("x q")}},
+		// Other DECRQSS replies
+		{"\x1bP1$r0m\x1b\\", DcsDataEvent{Params: []byte("1"), Intermediates: []byte("$"), Final: 'r', Data: []byte("0m")}},
+	}
+
+	for _, c := range cases {
+		if _, e := ParseSequence([]byte(c.seq)); !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+}
+
+func TestParseCursorPositionEvent(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		// CSI Pl ; Pc R
+		{"\x1b[24;80R", CursorPositionEvent{Row: 23, Col: 79}},
+		{"\x1b[2;1R", CursorPositionEvent{Row: 1}},
+		{"\x9b999;999R", CursorPositionEvent{Row: 998, Col: 998}},
+		// DECXCPR i.e. CSI ? Pl ; Pc ; Pp R
+		{"\x1b[?24;80;1R", CursorPositionEvent{Row: 23, Col: 79}},
+		{"\x1b[?1;1R", CursorPositionEvent{}},
+		{"\x1b[?1R", UnknownCsiEvent("\x1b[?1R")},
+		// The first row, past the modifier range
+		{"\x1b[1;17R", CursorPositionEvent{Col: 16}},
+		{"\x1b[1;80R", CursorPositionEvent{Col: 79}},
+		{"\x1b[1;1R", CursorPositionEvent{}},
+
+		// Keys that share the final byte
+		{"\x1b[R", KeyDownEvent{Sym: KeyF3}},
+		{"\x1b[5R", KeyDownEvent{Sym: KeyF3, Mod: Ctrl}},
+		{"\x1b[1;5R", KeyDownEvent{Sym: KeyF3, Mod: Ctrl}},
+		{"\x1b[1;2:3R", KeyUpEvent{Sym: KeyF3, Mod: Shift}},
+		{"\x1b[1;16R", KeyDownEvent{Sym: KeyF3, Mod: Shift | Alt | Ctrl | Meta}},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+
+	// The driver key table doesn't shadow reports.
+	in := "\x1b[24;80R\x1b[1;5R\x1b[3;7R\x1b[R"
+	want := []Event{
+		CursorPositionEvent{Row: 23, Col: 79},
+		KeyDownEvent{Sym: KeyF3, Mod: Ctrl},
+		CursorPositionEvent{Row: 2, Col: 6},
+		KeyDownEvent{Sym: KeyF3},
+	}
+	if got := DecodeString(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestCursorPositionF3Precedence(t *testing.T) {
+	// Keys and reports decoded by the same driver.
+	d := newDriver("", 0)
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		{"\x1b[1;5R", KeyDownEvent{Sym: KeyF3, Mod: Ctrl}},
+		{"\x1b[24;80R", CursorPositionEvent{Row: 23, Col: 79}},
+		{"\x1b[1;2R", KeyDownEvent{Sym: KeyF3, Mod: Shift}},
+		{"\x1b[1;24R", CursorPositionEvent{Col: 23}},
+		{"\x1b[5;1R", CursorPositionEvent{Row: 4}},
+		{"\x1b[R", KeyDownEvent{Sym: KeyF3}},
+		{"\x1bOR", KeyDownEvent{Sym: KeyF3}},
+		{"\x1b\x1b[1;5R", KeyDownEvent{Sym: KeyF3, Mod: Ctrl | Alt}},
+	}
+
+	for _, c := range cases {
+		if got, want := d.parser.decode([]byte(c.seq)), []Event{c.want}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %v, got %v", c.seq, want, got)
+		}
+	}
+
+	want := []Event{KeyDownEvent{Sym: KeyF3, Mod: Ctrl}, CursorPositionEvent{Row: 23, Col: 79}}
+	if got := d.parser.decode([]byte("\x1b[1;5R\x1b[24;80R")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -1,22 +1,48 @@
 package input
 
 import (
-	"fmt"
+	"bytes"
+	"strconv"
 )
 
 // PrimaryDeviceAttributesEvent represents a primary device attributes event.
+// This is the terminal response to a DA1 request i.e. CSI ? Ps ; ... c. The
+// first attribute is the terminal conformance level e.g. 62 for a VT220, and
+// the rest are the supported features e.g. 4 for Sixel graphics and 52 for
+// clipboard access (OSC 52).
 type PrimaryDeviceAttributesEvent []uint
 
 // String implements fmt.Stringer.
 func (e PrimaryDeviceAttributesEvent) String() string {
-	return fmt.Sprintf("%v", []uint(e))
+	return EventString(e)
 }
 
-func parsePrimaryDevAttrs(params [][]uint) Event {
-	// Primary Device Attributes
-	da1 := make([]uint, len(params))
-	for i, p := range params {
-		da1[i] = p[0]
+// Contains returns whether the terminal reported the attribute n.
+func (e PrimaryDeviceAttributesEvent) Contains(n uint) bool {
+	for _, a := range e {
+		if a == n {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrimaryDevAttrs parses the parameter bytes of a DA1 response without
+// the '?' marker i.e. Ps ; ... It returns nil if there are no attributes or
+// an attribute is empty or isn't a number.
+func parsePrimaryDevAttrs(p []byte) Event {
+	if len(p) == 0 {
+		return nil
+	}
+
+	parts := bytes.Split(p, []byte{';'})
+	da1 := make([]uint, len(parts))
+	for i, part := range parts {
+		n, err := strconv.ParseUint(string(part), 10, 16)
+		if err != nil {
+			return nil
+		}
+		da1[i] = uint(n)
 	}
 	return PrimaryDeviceAttributesEvent(da1)
 }
@@ -0,0 +1,56 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePrimaryDeviceAttributes(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		// xterm
+		{"\x1b[?64;1;2;6;9;15;16;17;18;21;22;28c", PrimaryDeviceAttributesEvent{64, 1, 2, 6, 9, 15, 16, 17, 18, 21, 22, 28}},
+		// VT100 with advanced video option
+		{"\x1b[?1;2c", PrimaryDeviceAttributesEvent{1, 2}},
+		// Sixel capable terminal
+		{"\x1b[?62;4;22c", PrimaryDeviceAttributesEvent{62, 4, 22}},
+		// 8-bit CSI
+		{"\x9b?62c", PrimaryDeviceAttributesEvent{62}},
+
+		// Malformed
+		{"\x1b[?c", UnknownCsiEvent("\x1b[?c")},
+		{"\x1b[?62;;4c", UnknownCsiEvent("\x1b[?62;;4c")},
+		{"\x1b[?62;4;c", UnknownCsiEvent("\x1b[?62;4;c")},
+		{"\x1b[?62:1;4c", UnknownCsiEvent("\x1b[?62:1;4c")},
+		{"\x1b[?62;99999c", UnknownCsiEvent("\x1b[?62;99999c")},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+}
+
+func TestPrimaryDeviceAttributesContains(t *testing.T) {
+	da1 := PrimaryDeviceAttributesEvent{62, 4, 22, 52}
+	for _, n := range []uint{62, 4, 22, 52} {
+		if !da1.Contains(n) {
+			t.Errorf("expected %v to contain %d", da1, n)
+		}
+	}
+	for _, n := range []uint{0, 1, 3} {
+		if da1.Contains(n) {
+			t.Errorf("expected %v not to contain %d", da1, n)
+		}
+	}
+	if (PrimaryDeviceAttributesEvent(nil)).Contains(0) {
+		t.Errorf("expected an empty event not to contain anything")
+	}
+}
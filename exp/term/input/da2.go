@@ -0,0 +1,81 @@
+package input
+
+import ()
+
+// SecondaryDeviceAttributesEvent represents a secondary device attributes
+// event. This is the terminal response to a DA2 request i.e. CSI > c.
+//
+// The response looks like CSI > Pp ; Pv ; Pc c where Pp is the terminal type,
+// Pv is the firmware version, and Pc is the ROM cartridge registration number
+// which is always zero.
+type SecondaryDeviceAttributesEvent []uint
+
+// TerminalType returns the terminal type identification code.
+func (e SecondaryDeviceAttributesEvent) TerminalType() uint {
+	if len(e) < 1 {
+		return 0
+	}
+	return e[0]
+}
+
+// Version returns the terminal firmware version number.
+func (e SecondaryDeviceAttributesEvent) Version() uint {
+	if len(e) < 2 {
+		return 0
+	}
+	return e[1]
+}
+
+// TerminalName returns the name of the terminal type reported by the
+// terminal. It returns an empty string if the terminal type is unknown.
+//
+// Note that many terminal emulators identify as VT100 or VT220 regardless of
+// what they actually are.
+func (e SecondaryDeviceAttributesEvent) TerminalName() string {
+	if len(e) < 1 {
+		return ""
+	}
+	return da2TerminalNames[e[0]]
+}
+
+// IsMultiplexer reports whether the reply came from a terminal multiplexer
+// i.e. tmux or GNU screen rather than the outer terminal.
+func (e SecondaryDeviceAttributesEvent) IsMultiplexer() bool {
+	switch e.TerminalType() {
+	case 83, 84: // 'S' screen, 'T' tmux
+		return true
+	}
+	return false
+}
+
+// String implements fmt.Stringer.
+func (e SecondaryDeviceAttributesEvent) String() string {
+	return EventString(e)
+}
+
+// da2TerminalNames maps DA2 terminal type codes to terminal names.
+var da2TerminalNames = map[uint]string{
+	0:  "VT100",
+	1:  "VT220",
+	2:  "VT240",
+	18: "VT330",
+	19: "VT340",
+	24: "VT320",
+	41: "VT420",
+	61: "VT510",
+	64: "VT520",
+	65: "VT525",
+
+	// Terminal multiplexers use the ASCII code of a letter.
+	83: "screen", // 'S'
+	84: "tmux",   // 'T'
+}
+
+func parseSecondaryDevAttrs(params [][]uint) Event {
+	// Secondary Device Attributes
+	da2 := make([]uint, len(params))
+	for i, p := range params {
+		da2[i] = p[0]
+	}
+	return SecondaryDeviceAttributesEvent(da2)
+}
@@ -0,0 +1,58 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSecondaryDeviceAttributes(t *testing.T) {
+	cases := []struct {
+		seq     string
+		want    SecondaryDeviceAttributesEvent
+		typ     uint
+		version uint
+		name    string
+	}{
+		// xterm
+		{"\x1b[>41;351;0c", SecondaryDeviceAttributesEvent{41, 351, 0}, 41, 351, "VT420"},
+		// VTE based terminals e.g. GNOME Terminal
+		{"\x1b[>65;6800;1c", SecondaryDeviceAttributesEvent{65, 6800, 1}, 65, 6800, "VT525"},
+		// kitty
+		{"\x1b[>1;4000;29c", SecondaryDeviceAttributesEvent{1, 4000, 29}, 1, 4000, "VT220"},
+		// tmux
+		{"\x1b[>84;0;0c", SecondaryDeviceAttributesEvent{84, 0, 0}, 84, 0, "tmux"},
+		// GNU screen
+		{"\x1b[>83;40800;0c", SecondaryDeviceAttributesEvent{83, 40800, 0}, 83, 40800, "screen"},
+		// Unknown terminal type
+		{"\x1b[>99;1c", SecondaryDeviceAttributesEvent{99, 1}, 99, 1, ""},
+		// Default parameters
+		{"\x1b[>c", SecondaryDeviceAttributesEvent{0}, 0, 0, "VT100"},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		da2, ok := e.(SecondaryDeviceAttributesEvent)
+		if !ok {
+			t.Errorf("%q: expected SecondaryDeviceAttributesEvent, got %T", c.seq, e)
+			continue
+		}
+		if !reflect.DeepEqual(da2, c.want) {
+			t.Errorf("%q: expected %v, got %v", c.seq, c.want, da2)
+		}
+		if da2.TerminalType() != c.typ {
+			t.Errorf("%q: expected type %d, got %d", c.seq, c.typ, da2.TerminalType())
+		}
+		if da2.Version() != c.version {
+			t.Errorf("%q: expected version %d, got %d", c.seq, c.version, da2.Version())
+		}
+		if multi := c.name == "tmux" || c.name == "screen"; da2.IsMultiplexer() != multi {
+			t.Errorf("%q: expected multiplexer %v, got %v", c.seq, multi, da2.IsMultiplexer())
+		}
+		if da2.TerminalName() != c.name {
+			t.Errorf("%q: expected name %q, got %q", c.seq, c.name, da2.TerminalName())
+		}
+	}
+}
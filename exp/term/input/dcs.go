@@ -0,0 +1,160 @@
+package input
+
+import (
+	"github.com/charmbracelet/x/exp/term/ansi"
+)
+
+// DcsDataEvent represents a DCS sequence that the driver doesn't specifically
+// parse e.g. Sixel graphics, DECRQSS, and checksum reports. It preserves the
+// sequence parts so that applications can handle them on their own.
+//
+//	DCS <params> <intermediates> <final> <data> ST
+type DcsDataEvent struct {
+	// Params is the raw parameter bytes of the sequence.
+	Params []byte
+
+	// Intermediates is the intermediate bytes of the sequence.
+	Intermediates []byte
+
+	// Final is the final byte of the sequence.
+	Final byte
+
+	// Data is the raw payload of the sequence between the final byte and the
+	// string terminator.
+	Data []byte
+}
+
+// String implements fmt.Stringer.
+func (e DcsDataEvent) String() string {
+	return EventString(e)
+}
+
+// dcsSequence is a framed DCS sequence.
+type dcsSequence struct {
+	params, inters []byte
+	final          byte
+	data           []byte
+}
+
+// scanDcs frames a DCS sequence introduced by DCS (0x90) or ESC P and
+// terminated by ST (0x9c) or ESC \. Some terminals terminate their replies
+// with BEL like OSC sequences, BEL is accepted as a terminator too. It
+// returns the number of bytes consumed and whether the sequence is complete.
+func scanDcs(p []byte) (n int, dcs dcsSequence, ok bool) {
+	var i int
+	if p[i] == ansi.DCS || p[i] == ansi.ESC {
+		i++
+	}
+	if i < len(p) && p[i-1] == ansi.ESC && p[i] == 'P' {
+		i++
+	}
+
+	// Scan parameter bytes in the range 0x30-0x3F
+	start := i
+	for ; i < len(p) && p[i] >= 0x30 && p[i] <= 0x3F; i++ {
+	}
+	dcs.params = p[start:i]
+
+	// Scan intermediate bytes in the range 0x20-0x2F
+	start = i
+	for ; i < len(p) && p[i] >= 0x20 && p[i] <= 0x2F; i++ {
+	}
+	dcs.inters = p[start:i]
+
+	if i < len(p) && isCancel(p[i]) {
+		return i + 1, dcs, false
+	}
+
+	// Scan final byte in the range 0x40-0x7E
+	if i >= len(p) || p[i] < 0x40 || p[i] > 0x7E {
+		return i, dcs, false
+	}
+	dcs.final = p[i]
+	i++
+
+	// Collect data bytes until a ST character is found
+	// data bytes are in the range of 0x08-0x0D and 0x20-0x7F
+	// but we don't care about the actual values for now
+	start = i
+	for ; i < len(p) && p[i] != ansi.ST && p[i] != ansi.ESC && p[i] != ansi.BEL && !isCancel(p[i]); i++ {
+	}
+	dcs.data = p[start:i]
+
+	if i >= len(p) {
+		return i, dcs, false
+	}
+	if isCancel(p[i]) {
+		return i + 1, dcs, false
+	}
+	if p[i] == ansi.BEL {
+		return i + 1, dcs, true
+	}
+
+	// Check 7-bit ST (string terminator) character
+	if p[i] == ansi.ESC {
+		if i+1 >= len(p) || p[i+1] != '\\' {
+			return i, dcs, false
+		}
+		i++
+	}
+
+	return i + 1, dcs, true
+}
+
+// parseDcsData parses a framed DCS sequence. Sequences that aren't
+// specifically handled are reported as DcsDataEvent.
+func parseDcsData(dcs dcsSequence) Event {
+	switch dcs.final {
+	case '|':
+		// XTVERSION responses
+		if len(dcs.params) == 1 && dcs.params[0] == '>' && len(dcs.inters) == 0 {
+			return TerminalVersionEvent{Name: string(dcs.data)}
+		}
+	case 'r':
+		if len(dcs.inters) == 0 {
+			break
+		}
+		switch dcs.inters[0] {
+		case '$':
+			// DECRQSS responses. Only valid replies are handled, an invalid
+			// reply i.e. DCS 0 $ r ST doesn't tell what was requested.
+			if string(dcs.params) != "1" {
+				break
+			}
+			// DECSCUSR i.e. DCS 1 $ r Ps SP q ST
+			if ps := dcs.data; len(ps) >= 2 && string(ps[len(ps)-2:]) == " q" {
+				if e, ok := parseCursorStyle(ps[:len(ps)-2]); ok {
+					return e
+				}
+			}
+		case '+':
+			// XTGETTCAP responses
+			params := ansi.Params(dcs.params)
+			if len(params) == 0 {
+				break
+			}
+
+			switch params[0][0] {
+			case 0, 1:
+				tc := parseTermcap(dcs.data)
+				// XXX: some terminals like KiTTY report invalid responses with
+				// their queries i.e. sending a query for "Tc" using "\x1bP+q5463\x1b\\"
+				// returns "\x1bP0+r5463\x1b\\".
+				// The specs says that invalid responses should be in the form of
+				// DCS 0 + r ST "\x1bP0+r\x1b\\"
+				//
+				// See: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Operating-System-Commands
+				tc.IsValid = params[0][0] == 1
+				return tc
+			}
+		}
+	}
+
+	// Copy the sequence parts, the input buffer might get reused.
+	return DcsDataEvent{
+		Params:        append([]byte(nil), dcs.params...),
+		Intermediates: append([]byte(nil), dcs.inters...),
+		Final:         dcs.final,
+		Data:          append([]byte(nil), dcs.data...),
+	}
+}
@@ -0,0 +1,60 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDcsData(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		// Sixel graphics
+		{"\x1bP0;1;0q#0;2;0;0;0#0!10~\x1b\\", DcsDataEvent{
+			Params: []byte("0;1;0"),
+			Final:  'q',
+			Data:   []byte("#0;2;0;0;0#0!10~"),
+		}},
+		// DECRQSS reply for SGR
+		{"\x1bP1$r0m\x1b\\", DcsDataEvent{
+			Params:        []byte("1"),
+			Intermediates: []byte("$"),
+			Final:         'r',
+			Data:          []byte("0m"),
+		}},
+		// 8-bit DCS and ST, DECCKSR checksum report
+		{"\x901!~A5F0\x9c", DcsDataEvent{
+			Params:        []byte("1"),
+			Intermediates: []byte("!"),
+			Final:         '~',
+			Data:          []byte("A5F0"),
+		}},
+		// No payload
+		{"\x1bP!~\x1b\\", DcsDataEvent{Intermediates: []byte("!"), Final: '~'}},
+		// XTGETTCAP replies are still handled specifically
+		{"\x1bP1+r5463\x1b\\", TermcapEvent{Values: map[string]string{"Tc": ""}, IsValid: true}},
+		// Unterminated sequences
+		{"\x1bP1$r0m", UnknownEvent("\x1bP1$r0m")},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+}
+
+func TestUnknownDcsIsNotKeys(t *testing.T) {
+	want := []Event{
+		DcsDataEvent{Params: []byte("1"), Intermediates: []byte("$"), Final: 'r', Data: []byte("1\"q")},
+		KeyDownEvent{Rune: 'a'},
+	}
+	if got := DecodeString("\x1bP1$r1\"q\x1b\\a"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,15 @@
+package input
+
+// DecodeString decodes all the events in the given string using the default
+// parser flags. It's useful to decode recorded terminal input without a
+// reader.
+func DecodeString(s string) []Event {
+	return DecodeStringWith(s, 0)
+}
+
+// DecodeStringWith is like DecodeString but decodes the string using a parser
+// configured with the given flags.
+func DecodeStringWith(s string, flags int) []Event {
+	p := newParser("", flags)
+	return append(p.decode([]byte(s)), p.flush()...)
+}
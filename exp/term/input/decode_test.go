@@ -0,0 +1,259 @@
+package input
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeStringWith(t *testing.T) {
+	cases := []struct {
+		name  string
+		flags int
+		in    string
+		want  []Event
+	}{
+		{
+			"default",
+			0,
+			"\r\t\x00 \x7fa",
+			[]Event{
+				KeyDownEvent{Sym: KeyEnter},
+				KeyDownEvent{Sym: KeyTab},
+				KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl},
+				KeyDownEvent{Sym: KeySpace, Rune: ' '},
+				KeyDownEvent{Sym: KeyBackspace},
+				KeyDownEvent{Rune: 'a'},
+			},
+		},
+		{
+			"ctrl+m and ctrl+i",
+			FlagCtrlM | FlagCtrlI,
+			"\r\t",
+			[]Event{
+				KeyDownEvent{Rune: 'm', Mod: Ctrl},
+				KeyDownEvent{Rune: 'i', Mod: Ctrl},
+			},
+		},
+		{
+			"ctrl+@ and backspace",
+			FlagCtrlAt | FlagBackspace,
+			"\x00\x7f",
+			[]Event{
+				KeyDownEvent{Rune: '@', Mod: Ctrl},
+				KeyDownEvent{Sym: KeyDelete},
+			},
+		},
+		{
+			"space and find/select",
+			FlagSpace | FlagFind | FlagSelect,
+			" \x1b[1~\x1b[4~",
+			[]Event{
+				KeyDownEvent{Rune: ' '},
+				KeyDownEvent{Sym: KeyFind},
+				KeyDownEvent{Sym: KeySelect},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DecodeStringWith(c.in, c.flags); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestDecodeString(t *testing.T) {
+	want := []Event{KeyDownEvent{Rune: 'a', Mod: Alt}, KeyDownEvent{Sym: KeyUp}}
+	if got := DecodeString("\x1ba\x1b[A"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDecodeKeyPressRelease(t *testing.T) {
+	// Kitty press, repeat, and release of the 'a' key.
+	in := "\x1b[97u\x1b[97;1:2u\x1b[97;1:3u"
+	press := KeyDownEvent{Rune: 'a'}
+	repeat := KeyDownEvent{Rune: 'a', IsRepeat: true}
+	release := KeyUpEvent{Rune: 'a'}
+
+	cases := []struct {
+		name  string
+		flags int
+		want  []Event
+	}{
+		{"both", 0, []Event{press, repeat, release}},
+		{"press only", FlagKeyPressOnly, []Event{press, repeat}},
+		{"release only", FlagKeyReleaseOnly, []Event{release}},
+		{"press only wins", FlagKeyPressOnly | FlagKeyReleaseOnly, []Event{press, repeat}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DecodeStringWith(in, c.flags); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+
+	both := WithFlags(FlagKeyPressOnly | FlagKeyReleaseOnly)
+	if _, err := NewParser(both); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected NewParser to return ErrInvalidConfig, got %v", err)
+	}
+	if _, err := NewDriver(strings.NewReader(""), WithFlags(FlagKeyPressOnly), WithFlags(FlagKeyReleaseOnly)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected NewDriver to return ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestDecodeKeyReleaseOnlyTableKeys(t *testing.T) {
+	// Whole-buffer table keys and 8-bit meta keys are key presses too.
+	cases := []struct {
+		in    string
+		flags int
+		press Event
+	}{
+		{"\t", 0, KeyDownEvent{Sym: KeyTab}},
+		{"\x1b[A", 0, KeyDownEvent{Sym: KeyUp}},
+		{"\xe1", FlagMeta8Bit, KeyDownEvent{Rune: 'a', Mod: Alt}},
+	}
+
+	for _, c := range cases {
+		if got := DecodeStringWith(c.in, c.flags|FlagKeyReleaseOnly); len(got) != 0 {
+			t.Errorf("%q: expected no events, got %v", c.in, got)
+		}
+		if got, want := DecodeStringWith(c.in, c.flags|FlagKeyPressOnly), []Event{c.press}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %v, got %v", c.in, want, got)
+		}
+	}
+}
+
+func TestDecodeMeta8Bit(t *testing.T) {
+	cases := []struct {
+		flags int
+		in    string
+		want  []Event
+	}{
+		{FlagMeta8Bit, "\xe1", []Event{KeyDownEvent{Rune: 'a', Mod: Alt}}},
+		{FlagMeta8Bit, "a\xe2c", []Event{KeyDownEvent{Rune: 'a'}, KeyDownEvent{Rune: 'b', Mod: Alt}, KeyDownEvent{Rune: 'c'}}},
+		{FlagMeta8Bit, "\xc1", []Event{KeyDownEvent{Rune: 'A', Mod: Alt}}},
+		{FlagMeta8Bit, "\x8d", []Event{KeyDownEvent{Sym: KeyEnter, Mod: Alt}}},
+		{FlagMeta8Bit, "\x81", []Event{KeyDownEvent{Rune: 'a', Mod: Ctrl | Alt}}},
+		{FlagMeta8Bit, "\xa0", []Event{KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Alt}}},
+		{FlagMeta8Bit | FlagCtrlM, "\x8d", []Event{KeyDownEvent{Rune: 'm', Mod: Ctrl | Alt}}},
+		{FlagMeta8Bit, "\x1b[A", []Event{KeyDownEvent{Sym: KeyUp}}},
+
+		// Without the flag, the input is UTF-8.
+		{0, "é", []Event{KeyDownEvent{Rune: 'é'}}},
+		{FlagMeta8Bit, "é", []Event{KeyDownEvent{Rune: 'C', Mod: Alt}, KeyDownEvent{Rune: ')', Mod: Alt}}},
+	}
+
+	for _, c := range cases {
+		if got := DecodeStringWith(c.in, c.flags); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: expected %v, got %v", c.in, c.want, got)
+		}
+	}
+}
+
+func TestDecodeNormalizeShift(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []Event
+	}{
+		{"legacy", "A", []Event{KeyDownEvent{Rune: 'A'}}},
+		{"kitty", "\x1b[97;2u", []Event{KeyDownEvent{Rune: 'A'}}},
+		{"kitty release", "\x1b[97;2:3u", []Event{KeyUpEvent{Rune: 'A'}}},
+		{"kitty alternate key", "\x1b[49:33;2u", []Event{KeyDownEvent{Rune: '!'}}},
+		{"kitty without alternate key", "\x1b[49;2u", []Event{KeyDownEvent{Rune: '1', Mod: Shift}}},
+		{"kitty other modifiers", "\x1b[97;6u", []Event{KeyDownEvent{Rune: 'A', Mod: Ctrl}}},
+		{"modifyOtherKeys", "\x1b[27;2;97~", []Event{KeyDownEvent{Rune: 'A'}}},
+		{"symbols", "\x1b[1;2A", []Event{KeyDownEvent{Sym: KeyUp, Mod: Shift}}},
+		{"non-ascii letters", "\x1b[233;2u", []Event{KeyDownEvent{Rune: 'É'}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DecodeStringWith(c.in, FlagNormalizeShift); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+
+	// Both representations of shift+a are the same after normalization.
+	if legacy, kitty := DecodeStringWith("A", FlagNormalizeShift), DecodeStringWith("\x1b[97;2u", FlagNormalizeShift); !reflect.DeepEqual(legacy, kitty) {
+		t.Errorf("expected %v and %v to be the same", legacy, kitty)
+	}
+}
+
+func TestC1Bytes(t *testing.T) {
+	cases := []struct {
+		name   string
+		flags  int
+		chunks []string
+		want   []Event
+	}{
+		{
+			"8-bit csi",
+			0,
+			[]string{"\x9bA"},
+			[]Event{KeyDownEvent{Sym: KeyUp}},
+		},
+		{
+			"8-bit ss3",
+			0,
+			[]string{"\x8fA\x8fP"},
+			[]Event{KeyDownEvent{Sym: KeyUp}, KeyDownEvent{Sym: KeyF1}},
+		},
+		{
+			"8-bit modified keys",
+			0,
+			[]string{"\x9b1;5A\x8f5A\x9b3;3~"},
+			[]Event{
+				KeyDownEvent{Sym: KeyUp, Mod: Ctrl},
+				KeyDownEvent{Sym: KeyUp, Mod: Ctrl},
+				KeyDownEvent{Sym: KeyDelete, Mod: Alt},
+			},
+		},
+		{
+			"8-bit keys only in the table",
+			0,
+			[]string{"\x9b7$\x9b23$"},
+			[]Event{KeyDownEvent{Sym: KeyHome, Mod: Shift}, KeyDownEvent{Sym: KeyF11, Mod: Shift}},
+		},
+		{
+			"no c1",
+			FlagNoC1,
+			[]string{"\x9bA"},
+			[]Event{KeyDownEvent{Rune: 'A'}},
+		},
+		{
+			"rune split before a c1 continuation byte",
+			0,
+			[]string{"\xe2\x94", "\x9bA"},
+			[]Event{KeyDownEvent{Rune: '┛'}, KeyDownEvent{Rune: 'A'}},
+		},
+		{
+			"rune split after the lead byte",
+			0,
+			[]string{"a\xe2", "\x94\x9b"},
+			[]Event{KeyDownEvent{Rune: 'a'}, KeyDownEvent{Rune: '┛'}},
+		},
+		{
+			"truncated rune at EOF",
+			0,
+			[]string{"\xe2\x94"},
+			[]Event{UnknownEvent("\xe2\x94")},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := readEvents(t, c.flags, c.chunks...); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
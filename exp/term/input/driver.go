@@ -1,8 +1,11 @@
 package input
 
 import (
+	"errors"
 	"io"
-	"unicode/utf8"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/erikgeiser/coninput"
 	"github.com/muesli/cancelreader"
@@ -15,7 +18,8 @@ const (
 	//
 	// Historically, the ANSI specs generate NUL (0x00) on both the Ctrl+Space
 	// and Ctrl+@ key sequences. This flag allows the driver to treat both as
-	// the same key sequence.
+	// the same key sequence. It takes precedence over FlagSpace, NUL is
+	// reported as ctrl+@ when both are set.
 	FlagCtrlAt = 1 << iota
 
 	// When this flag is set, the driver will treat the Tab key and Ctrl+I as
@@ -42,7 +46,8 @@ const (
 	FlagCtrlOpenBracket
 
 	// When this flag is set, the driver will treat space as a key rune instead
-	// of a key symbol.
+	// of a key symbol. This also applies to NUL (ctrl+space) unless
+	// FlagCtrlAt is set.
 	FlagSpace
 
 	// When this flag is set, the driver will send a BS (0x08 byte) character
@@ -89,20 +94,110 @@ const (
 	// Key definitions come from Terminfo, this flag is only useful when
 	// FlagTerminfo is not set.
 	FlagFKeys
+
+	// When this flag is set, the driver will report the distance the mouse
+	// moved since the last mouse event in the DX and DY fields of mouse
+	// events. The deltas are reset on every button press.
+	FlagMouseDelta
+
+	// When this flag is set, the driver will only report key presses and drop
+	// key release events.
+	//
+	// This is the legacy behavior of terminals that don't report key releases.
+	FlagKeyPressOnly
+
+	// When this flag is set, the driver will only report key releases and drop
+	// key press and repeat events. NewDriver and NewParser reject it together
+	// with FlagKeyPressOnly, DecodeStringWith lets FlagKeyPressOnly win.
+	//
+	// Only use this with terminals that report key releases, e.g. Kitty with
+	// the ReportEventTypes flag, otherwise no key events will be reported.
+	FlagKeyReleaseOnly
+
+	// When this flag is set, the driver will decode the Unicode control
+	// pictures (U+2400-U+2421) as the control keys they picture i.e. ␀ (U+2400)
+	// is decoded the same as NUL (0x00).
+	//
+	// Some legacy terminals and session recorders use control pictures in
+	// place of control bytes.
+	FlagCtrlPictures
+
+	// When this flag is set, the driver will decode bytes with the high bit
+	// set as Alt + <key> i.e. 0xe1 is decoded as alt+a. This is used by
+	// terminals configured to send 8-bit meta characters instead of
+	// prefixing keys with ESC.
+	//
+	// This is mutually exclusive with UTF-8 and 8-bit C1 control sequences.
+	// When set, multi-byte UTF-8 characters and C1 sequences such as CSI
+	// (0x9b) won't be decoded, use it only when the terminal input is not
+	// UTF-8.
+	FlagMeta8Bit
+
+	// When this flag is set, the driver will report held down keys that the
+	// terminal reports as a single event with a repeat count, e.g. Windows
+	// Console, as a single event with IsRepeat and RepeatCount set instead of
+	// one event per repeat. WithExpandRepeats sets and clears it.
+	FlagCollapseRepeats
+
+	// When this flag is set, the driver will recognize Sun function key
+	// sequences i.e. CSI <number> z. These are used by Sun keyboards and
+	// XTerm's Sun function-key mode. They're also enabled when $TERM starts
+	// with "sun".
+	FlagSunKeys
+
+	// When this flag is set, the driver will report shift-modified printable
+	// keys as their shifted rune without the Shift modifier i.e. shift+a is
+	// reported as 'A'. This is how legacy terminals report these keys, while
+	// Kitty and XTerm modifyOtherKeys report the base rune with the Shift
+	// modifier.
+	//
+	// Letters are always normalized. Other keys are only normalized when the
+	// terminal reports the shifted key, e.g. Kitty with ReportAlternateKeys.
+	FlagNormalizeShift
+
+	// When this flag is set, the driver will report SGR mouse event
+	// coordinates as pixels. Use it when SGR-Pixels mouse mode (DECSET 1016)
+	// is enabled. Pixel coordinates are zero-based offsets from the upper
+	// left corner of the terminal and are reported as is.
+	FlagMousePixels
+
+	// When this flag is set, the driver won't decode standalone bytes in the
+	// 0x80-0x9f range as 8-bit C1 control characters e.g. CSI (0x9b).
+	//
+	// In a UTF-8 stream, these bytes only appear as continuation bytes of a
+	// multi-byte rune. A standalone 0x9b is either a rune truncated by a read
+	// or an 8-bit CSI. By default, the driver waits for the rest of a rune
+	// started by a previous read, and decodes any other standalone C1 byte as
+	// a control character. Use this flag when the terminal never sends 8-bit
+	// controls to ignore them instead.
+	FlagNoC1
+
+	// When this flag is set, the driver will count consecutive mouse presses
+	// of the same button in the same cell and report the count in the Clicks
+	// field of mouse press events i.e. 2 for a double-click and 3 for a
+	// triple-click. Use SetClickInterval to change the maximum time between
+	// presses.
+	FlagMouseClicks
+
+	// When this flag is set, the Windows driver will report the numeric
+	// keypad navigation keys, i.e. the keypad keys with NumLock off, and
+	// the keypad Enter key as KeyKp* keys e.g. KeyKpHome instead of KeyHome.
+	// By default, these are reported the same as the main keys. The Windows
+	// Console API tells them apart by the ENHANCED_KEY flag, which is only
+	// set for the dedicated navigation keys and the keypad Enter key.
+	FlagKeypadNav
 )
 
 // Driver represents an ANSI terminal input Driver.
 // It reads input events and parses ANSI sequences from the terminal input
 // buffer.
 type Driver struct {
-	rd    cancelreader.CancelReader
-	table map[string]KeyDownEvent
-
-	term string // the $TERM name to use
+	// parser decodes the input bytes into events. The driver forwards its
+	// configuration methods, but not Parse and Flush, which would share the
+	// decoding state with ReadEvents, ReadInput, and PeekInput.
+	parser Parser
 
-	// paste is the bracketed paste mode buffer.
-	// When nil, bracketed paste mode is disabled.
-	paste []byte
+	rd cancelreader.CancelReader
 
 	internalEvents []Event   // holds peeked events
 	buf            [256]byte // do we need a larger buffer?
@@ -111,42 +206,145 @@ type Driver struct {
 	// up button events.
 	prevMouseState coninput.ButtonState
 
-	// flags to control the behavior of the driver.
-	flags int
+	// reads delivers the reads of the background reader used by ReadEvents.
+	// The reader stops when done is closed by Cancel or Close. reading is
+	// set once ReadEvents starts the reader.
+	reads     chan readResult
+	readsOnce sync.Once
+	reading   uint32
+	done      chan struct{}
+	doneOnce  sync.Once
+
+	// readErr is the error the background reader stopped with.
+	readErr error
+
+	// escTimeout is how long ReadEvents waits for more input after a
+	// trailing ESC.
+	escTimeout time.Duration
+
+	// after is time.After. It's replaced in tests.
+	after func(time.Duration) <-chan time.Time
 }
 
 // NewDriver returns a new ANSI input driver.
 // This driver uses ANSI control codes compatible with VT100/VT200 terminals,
 // and XTerm. It supports reading Terminfo databases to overwrite the default
-// key sequences.
-func NewDriver(r io.Reader, term string, flags int) (*Driver, error) {
-	d := new(Driver)
-	d.internalEvents = make([]Event, 0, 10) // initial size of 10
+// key sequences. Use options to configure the driver e.g.
+//
+//	d, err := NewDriver(os.Stdin,
+//		WithTerminfo(os.Getenv("TERM")),
+//		WithFlags(FlagMouseDelta),
+//	)
+func NewDriver(r io.Reader, opts ...Option) (*Driver, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
 
 	cr, err := newCancelreader(r)
 	if err != nil {
 		return nil, err
 	}
 
+	d := newDriver(o.term, o.flags)
+	o.apply(&d.parser)
 	d.rd = cr
-	d.flags = flags
-	d.term = term
-	// Populate the key sequences table.
-	d.registerKeys(flags)
+	d.SetEscTimeout(o.escTimeout)
 	return d, nil
 }
 
-// Cancel cancels the underlying reader.
+// newDriver returns a new driver without an underlying reader. Use decode to
+// feed it input.
+func newDriver(term string, flags int) *Driver {
+	d := new(Driver)
+	d.parser.init(term, flags)
+	d.internalEvents = make([]Event, 0, 10) // initial size of 10
+	d.escTimeout = DefaultEscTimeout
+	d.after = time.After
+	d.done = make(chan struct{})
+	return d
+}
+
+// Cancel cancels the underlying reader. It stops the background reader of
+// ReadEvents.
 func (d *Driver) Cancel() bool {
+	d.stop()
 	return d.rd.Cancel()
 }
 
-// Close closes the underlying reader.
+// Close closes the underlying reader. It stops the background reader of
+// ReadEvents.
 func (d *Driver) Close() error {
+	d.stop()
 	return d.rd.Close()
 }
 
+// SetClickInterval sets the maximum time between consecutive mouse presses to
+// count them as a multi-click, see Parser.SetClickInterval.
+func (d *Driver) SetClickInterval(interval time.Duration) {
+	d.parser.SetClickInterval(interval)
+}
+
+// SetC0Handler sets the key event reported for the C0 control character c,
+// see Parser.SetC0Handler.
+func (d *Driver) SetC0Handler(c byte, k KeyDownEvent) {
+	d.parser.SetC0Handler(c, k)
+}
+
+// RegisterKey registers the key event reported for the key sequence seq, see
+// Parser.RegisterKey.
+func (d *Driver) RegisterKey(seq string, k KeyDownEvent) error {
+	return d.parser.RegisterKey(seq, k)
+}
+
+// RegisterKeys registers multiple key sequences at once, see
+// Parser.RegisterKeys.
+func (d *Driver) RegisterKeys(keys map[string]KeyDownEvent) error {
+	return d.parser.RegisterKeys(keys)
+}
+
+// UnregisterKey removes the key sequence seq, see Parser.UnregisterKey.
+func (d *Driver) UnregisterKey(seq string) {
+	d.parser.UnregisterKey(seq)
+}
+
+// Lookup returns the key event registered for the key sequence seq, see
+// Parser.Lookup.
+func (d *Driver) Lookup(seq string) (KeyDownEvent, bool) {
+	return d.parser.Lookup(seq)
+}
+
+// Table returns a copy of the key table of the driver, see Parser.Table.
+func (d *Driver) Table() map[string]KeyDownEvent {
+	return d.parser.Table()
+}
+
+// SetModifyOtherKeys sets the XTerm modifyOtherKeys mode the terminal is in,
+// see Parser.SetModifyOtherKeys.
+func (d *Driver) SetModifyOtherKeys(mode ModifyOtherKeysEvent) {
+	d.parser.SetModifyOtherKeys(mode)
+}
+
+// stop stops the background reader of ReadEvents.
+func (d *Driver) stop() {
+	d.doneOnce.Do(func() { close(d.done) })
+}
+
+// checkMixedRead returns ErrMixedRead if the driver is read with ReadEvents.
+func (d *Driver) checkMixedRead() error {
+	if atomic.LoadUint32(&d.reading) != 0 {
+		return ErrMixedRead
+	}
+	return nil
+}
+
 func (d *Driver) readInput(e []Event) (n int, err error) {
+	if err := d.checkMixedRead(); err != nil {
+		return 0, err
+	}
 	if len(e) == 0 {
 		return 0, nil
 	}
@@ -174,6 +372,9 @@ func (d *Driver) readInput(e []Event) (n int, err error) {
 }
 
 func (d *Driver) peekInput(n int) ([]Event, error) {
+	if err := d.checkMixedRead(); err != nil {
+		return nil, err
+	}
 	if n <= 0 {
 		return []Event{}, nil
 	}
@@ -186,64 +387,24 @@ func (d *Driver) peekInput(n int) ([]Event, error) {
 		n -= len(d.internalEvents)
 	}
 
-	// Peek new events
-	nb, err := d.rd.Read(d.buf[:])
-	if err != nil {
-		return nil, err
-	}
-
-	buf := d.buf[:nb]
-
-	// Lookup table first
-	if k, ok := d.table[string(buf)]; ok {
-		d.internalEvents = append(d.internalEvents, k)
-		return d.internalEvents, nil
-	}
-
-	var i int
-	for i < len(buf) {
-		nb, ev := ParseSequence(buf[i:])
-
-		// Handle bracketed-paste
-		if d.paste != nil {
-			if _, ok := ev.(PasteEndEvent); !ok {
-				d.paste = append(d.paste, buf[i])
-				i++
-				continue
-			}
+	// Peek new events. Keep reading until we get at least one event, the
+	// input might be an incomplete sequence that needs more bytes.
+	for {
+		nb, err := d.rd.Read(d.buf[:])
+		if errors.Is(err, io.EOF) && (len(d.parser.pending) > 0 || d.parser.paste != nil) {
+			// No more bytes are coming, flush what we have.
+			d.internalEvents = append(d.internalEvents, d.parser.flush()...)
+			break
 		}
-
-		switch ev.(type) {
-		case UnknownCsiEvent, UnknownSs3Event, UnknownEvent:
-			// If the sequence is not recognized by the parser, try looking it up.
-			if k, ok := d.table[string(buf[i:i+nb])]; ok {
-				ev = k
-			}
-		case PasteStartEvent:
-			d.paste = []byte{}
-		case PasteEndEvent:
-			// Decode the captured data into runes.
-			var paste []rune
-			for len(d.paste) > 0 {
-				r, w := utf8.DecodeRune(d.paste)
-				if r != utf8.RuneError {
-					paste = append(paste, r)
-				}
-				d.paste = d.paste[w:]
-			}
-			d.paste = nil // reset the buffer
-			d.internalEvents = append(d.internalEvents, PasteEvent(paste))
-		case nil:
-			i++
-			continue
+		if err != nil {
+			return nil, err
 		}
 
-		if mevs, ok := ev.(MultiEvent); ok {
-			d.internalEvents = append(d.internalEvents, []Event(mevs)...)
-		} else {
-			d.internalEvents = append(d.internalEvents, ev)
+		events := d.parser.decode(d.buf[:nb])
+		d.internalEvents = append(d.internalEvents, events...)
+		if len(events) > 0 {
+			break
 		}
-		i += nb
 	}
 
 	if len(d.internalEvents) >= n {
@@ -0,0 +1,78 @@
+package input
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readEvents reads all the events from the given input using a driver
+// configured with the given flags. Each input chunk is delivered by a
+// separate read.
+func readEvents(t *testing.T, flags int, in ...string) []Event {
+	t.Helper()
+
+	var rds []io.Reader
+	for _, s := range in {
+		rds = append(rds, strings.NewReader(s))
+	}
+
+	d, err := NewDriver(io.MultiReader(rds...), WithFlags(flags))
+	if err != nil {
+		t.Fatalf("unexpected error creating driver: %v", err)
+	}
+
+	var events []Event
+	var buf [16]Event
+	for {
+		n, err := d.ReadInput(buf[:])
+		events = append(events, buf[:n]...)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading input: %v", err)
+		}
+	}
+
+	return events
+}
+
+func TestIncompleteSGRMouse(t *testing.T) {
+	cases := []struct {
+		name   string
+		chunks []string
+		want   []Event
+	}{
+		{
+			"split before the final byte",
+			[]string{"\x1b[<0;10;10", "M"},
+			[]Event{MouseDownEvent{X: 9, Y: 9, Button: MouseButtonLeft}},
+		},
+		{
+			"split in the middle",
+			[]string{"a\x1b[<0;1", "0;10m", "b"},
+			[]Event{KeyDownEvent{Rune: 'a'}, MouseUpEvent{X: 9, Y: 9, Button: MouseButtonLeft}, KeyDownEvent{Rune: 'b'}},
+		},
+		{
+			"split three ways",
+			[]string{"\x1b[<", "35;2;3", "M"},
+			[]Event{MouseMoveEvent{X: 1, Y: 2}},
+		},
+		{
+			"never completed",
+			[]string{"\x1b[<0;10;10"},
+			[]Event{UnknownEvent("\x1b[<0;10;10")},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := readEvents(t, 0, c.chunks...); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package input
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/exp/term/ansi"
+)
+
+// Encode returns the canonical escape sequence for the key given the driver
+// flags, the inverse of decoding it. Keys are encoded the way XTerm reports
+// them, e.g. ctrl+up is CSI 1 ; 5 A, a ctrl+letter is its C0 control
+// character, and a rune without modifiers is its UTF-8 encoding. Modified
+// runes that don't have a legacy encoding use the XTerm modifyOtherKeys
+// encoding.
+//
+// Encoding always uses the generic key table for the flags. The terminal
+// profile, Terminfo, and the keys registered on a Driver or Parser don't
+// apply, use Parser.Table to look up the sequences of a specific terminal.
+//
+// The repeat state, the alternate runes, and the text of the key are not
+// encoded. It returns nil if the key can't be encoded.
+func (k KeyDownEvent) Encode(flags int) []byte {
+	k.IsRepeat, k.RepeatCount = false, 0
+	k.BaseRune, k.AltRune, k.ShiftedRune, k.Text = 0, 0, 0, ""
+	if seq, ok := encodeTable(flags)[k]; ok {
+		return []byte(seq)
+	}
+
+	switch {
+	case k.Mod.IsAlt():
+		// Alt + <key> is encoded as ESC + <key>
+		k.Mod &^= Alt
+		if seq := k.Encode(flags); seq != nil {
+			return append([]byte{ansi.ESC}, seq...)
+		}
+	case k.Sym == KeyNone && utf8.ValidRune(k.Rune) && k.Rune != 0:
+		if k.Mod == 0 {
+			return []byte(string(k.Rune))
+		}
+		// CSI 27 ; <modifier> ; <code> ~
+		return []byte("\x1b[27;" + strconv.FormatUint(uint64(encodeXTermModifier(k.Mod)), 10) +
+			";" + strconv.Itoa(int(k.Rune)) + "~")
+	}
+
+	return nil
+}
+
+var (
+	encodeTablesMu sync.Mutex
+	encodeTables   = map[int]map[KeyDownEvent]string{}
+)
+
+// encodeTable returns the reverse key table of the generic profile for the
+// given driver flags. Keys that are reported by more than one sequence map to
+// the canonical one. The table of each flags combination is built on first
+// use and kept for the lifetime of the program.
+func encodeTable(flags int) map[KeyDownEvent]string {
+	encodeTablesMu.Lock()
+	defer encodeTablesMu.Unlock()
+
+	if t, ok := encodeTables[flags]; ok {
+		return t
+	}
+
+	// Profile and Terminfo keys are not canonical, the driver has no
+	// terminal name.
+	d := newDriver("", flags)
+	t := make(map[KeyDownEvent]string, len(d.parser.table))
+	for seq, k := range d.parser.table {
+		if cur, ok := t[k]; !ok || lessCanonical(seq, cur) {
+			t[k] = seq
+		}
+	}
+
+	encodeTables[flags] = t
+	return t
+}
+
+// lessCanonical reports whether sequence a is preferred over sequence b to
+// encode a key. XTerm sequences are preferred over URxvt ones, and modified
+// sequences over ESC prefixed Alt sequences. Application cursor keys (SS3)
+// are only preferred for F1-F4, like XTerm does.
+func lessCanonical(a, b string) bool {
+	ra, rb := seqRank(a), seqRank(b)
+	for i := range ra {
+		if ra[i] != rb[i] {
+			return ra[i] < rb[i]
+		}
+	}
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// seqRank returns the penalties of a sequence in order of importance.
+func seqRank(seq string) [4]int {
+	var rank [4]int
+
+	s := seq
+	if len(s) > 1 && s[0] == ansi.ESC && s[1] == ansi.ESC {
+		// ESC prefixed Alt sequence
+		rank[2] = 1
+		s = s[1:]
+	}
+	if len(s) < 3 || s[0] != ansi.ESC || (s[1] != '[' && s[1] != 'O') {
+		return rank
+	}
+
+	final := s[len(s)-1]
+	params := s[2 : len(s)-1]
+	isFn := final >= 'P' && final <= 'S'
+	switch {
+	case final == '$' || final == '^' || final == '@',
+		final >= 'a' && final <= 'd' && params == "":
+		// URxvt keys
+		rank[0] = 1
+	case isFn && params != "" && !strings.Contains(params, ";"):
+		// Legacy modified F1-F4 without the leading 1 param
+		rank[1] = 1
+	case s[1] == 'O' && !isFn:
+		rank[3] = 1
+	case s[1] == '[' && isFn && params == "":
+		// F1-F4 are reported as SS3 by XTerm
+		rank[3] = 1
+	}
+
+	return rank
+}
@@ -0,0 +1,76 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyEncode(t *testing.T) {
+	cases := []struct {
+		flags int
+		key   KeyDownEvent
+		want  string
+	}{
+		{0, KeyDownEvent{Rune: 'a'}, "a"},
+		{0, KeyDownEvent{Rune: 'ж'}, "ж"},
+		{0, KeyDownEvent{Rune: 'a', Mod: Ctrl}, "\x01"},
+		{0, KeyDownEvent{Rune: 'a', Mod: Alt}, "\x1ba"},
+		{0, KeyDownEvent{Rune: 'a', Mod: Ctrl | Alt}, "\x1b\x01"},
+		{0, KeyDownEvent{Rune: 'a', Mod: Ctrl | Shift}, "\x1b[27;6;97~"},
+		{0, KeyDownEvent{Sym: KeySpace, Rune: ' '}, " "},
+		{0, KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl}, "\x00"},
+		{0, KeyDownEvent{Sym: KeyEnter}, "\r"},
+		{0, KeyDownEvent{Sym: KeyTab, Mod: Shift}, "\x1b[Z"},
+		{0, KeyDownEvent{Sym: KeyUp}, "\x1b[A"},
+		{0, KeyDownEvent{Sym: KeyUp, Mod: Ctrl}, "\x1b[1;5A"},
+		{0, KeyDownEvent{Sym: KeyUp, Mod: Alt}, "\x1b[1;3A"},
+		{0, KeyDownEvent{Sym: KeyUp, Mod: Ctrl, IsRepeat: true}, "\x1b[1;5A"},
+		{0, KeyDownEvent{Sym: KeyF1}, "\x1bOP"},
+		{0, KeyDownEvent{Sym: KeyF1, Mod: Shift}, "\x1b[1;2P"},
+		{0, KeyDownEvent{Sym: KeyF5}, "\x1b[15~"},
+		{0, KeyDownEvent{Sym: KeyDelete, Mod: Shift}, "\x1b[3;2~"},
+		{0, KeyDownEvent{Sym: KeyKpEnter}, "\x1bOM"},
+		{0, KeyDownEvent{Sym: KeyHome}, "\x1b[H"},
+		{FlagFind, KeyDownEvent{Sym: KeyFind}, "\x1b[1~"},
+		{FlagCtrlAt, KeyDownEvent{Rune: '@', Mod: Ctrl}, "\x00"},
+		{0, KeyDownEvent{}, ""},
+	}
+
+	for _, c := range cases {
+		if got := string(c.key.Encode(c.flags)); got != c.want {
+			t.Errorf("%v: expected %q, got %q", c.key, c.want, got)
+		}
+	}
+}
+
+func TestKeyEncodeRoundTrip(t *testing.T) {
+	for _, flags := range []int{
+		0,
+		FlagSpace | FlagCtrlAt | FlagFind | FlagSelect | FlagBackspace,
+		FlagCtrlI | FlagCtrlM | FlagCtrlOpenBracket | FlagSunKeys,
+		FlagNoXTerm,
+	} {
+		d := newDriver("", flags)
+		decode := func(s string) []Event {
+			return append(d.parser.decode([]byte(s)), d.parser.flush()...)
+		}
+		for seq := range d.parser.table {
+			events := decode(seq)
+			if len(events) != 1 {
+				t.Errorf("flags %d: %q: expected a single event, got %v", flags, seq, events)
+				continue
+			}
+
+			k, ok := events[0].(KeyDownEvent)
+			if !ok {
+				t.Errorf("flags %d: %q: expected a key, got %#v", flags, seq, events[0])
+				continue
+			}
+
+			enc := k.Encode(flags)
+			if got := decode(string(enc)); !reflect.DeepEqual(got, events) {
+				t.Errorf("flags %d: %q: encoded as %q which decodes to %#v, expected %#v", flags, seq, enc, got, events)
+			}
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package input
+
+// FocusEvent represents a terminal focus event. This occurs when the terminal
+// gains focus and focus reporting (DECSET 1004) is enabled i.e. CSI I.
+type FocusEvent struct{}
+
+// String implements fmt.Stringer.
+func (e FocusEvent) String() string {
+	return EventString(e)
+}
+
+// BlurEvent represents a terminal blur event. This occurs when the terminal
+// loses focus and focus reporting (DECSET 1004) is enabled i.e. CSI O.
+type BlurEvent struct{}
+
+// String implements fmt.Stringer.
+func (e BlurEvent) String() string {
+	return EventString(e)
+}
@@ -0,0 +1,43 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFocusEvents(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []Event
+	}{
+		{"focus", "\x1b[I", []Event{FocusEvent{}}},
+		{"blur", "\x1b[O", []Event{BlurEvent{}}},
+		{"8-bit", "\x9bI\x9bO", []Event{FocusEvent{}, BlurEvent{}}},
+		{
+			"interleaved with keys",
+			"a\x1b[I\x1b[Ab\x1b[O\x1bOA",
+			[]Event{
+				KeyDownEvent{Rune: 'a'},
+				FocusEvent{},
+				KeyDownEvent{Sym: KeyUp},
+				KeyDownEvent{Rune: 'b'},
+				BlurEvent{},
+				KeyDownEvent{Sym: KeyUp},
+			},
+		},
+		{
+			"with parameters",
+			"\x1b[1I",
+			[]Event{UnknownCsiEvent("\x1b[1I")},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DecodeString(c.in); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package input
+
+import (
+	"fmt"
+	"image/color"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EventString returns a canonical representation of the event for tests
+// and debugging e.g. golden files. It reports the event type followed by all
+// of its fields in declaration order i.e.
+//
+//	KeyDownEvent{Rune: 'a', ..., Mod: ctrl}
+//	UnknownCsiEvent("\x1b[?u")
+//	PrimaryDeviceAttributesEvent[62, 22]
+//
+// Runes, bytes, and strings are quoted, colors are reported in hex,
+// modifiers, key symbols, and mouse buttons are reported by name unless
+// they're zero, and map entries are sorted by key. The events of a
+// MultiEvent are reported in order.
+//
+// The String methods of the events return it, except for the key and mouse
+// events, which return their name e.g. "ctrl+a", the format read by ParseKey,
+// or "shift+left".
+func EventString(e Event) string {
+	if e == nil {
+		return "nil"
+	}
+
+	v := reflect.ValueOf(e)
+	var sb strings.Builder
+	sb.WriteString(v.Type().Name())
+	switch v.Kind() {
+	case reflect.Struct:
+		writeFields(&sb, v)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			sb.WriteString("(" + formatValue(v, false) + ")")
+		} else {
+			sb.WriteString(formatValue(v, false))
+		}
+	default:
+		sb.WriteString("(" + formatValue(v, false) + ")")
+	}
+	return sb.String()
+}
+
+var colorType = reflect.TypeOf((*color.Color)(nil)).Elem()
+
+// writeFields writes the exported fields of the struct as {Name: value, ...}.
+func writeFields(sb *strings.Builder, v reflect.Value) {
+	sb.WriteByte('{')
+	var n int
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" {
+			// Unexported
+			continue
+		}
+		if n > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(f.Name + ": " + formatValue(v.Field(i), true))
+		n++
+	}
+	sb.WriteByte('}')
+}
+
+// formatValue formats a field value. Named types implementing fmt.Stringer
+// are reported by name when stringer is true and the value isn't zero.
+func formatValue(v reflect.Value, stringer bool) string {
+	if v.Type() == colorType {
+		if v.IsNil() {
+			return "nil"
+		}
+		return colorToHex(v.Interface().(color.Color))
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return "nil"
+		}
+		if v.Kind() == reflect.Interface {
+			return EventString(v.Interface())
+		}
+		return formatValue(v.Elem(), stringer)
+	case reflect.Struct:
+		var sb strings.Builder
+		sb.WriteString(v.Type().Name())
+		writeFields(&sb, v)
+		return sb.String()
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return strconv.Quote(string(v.Bytes()))
+		}
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elems[i] = formatValue(v.Index(i), stringer)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case reflect.Map:
+		entries := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			entries = append(entries, formatValue(k, stringer)+": "+formatValue(v.MapIndex(k), stringer))
+		}
+		sort.Strings(entries)
+		return "{" + strings.Join(entries, ", ") + "}"
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+
+	if stringer && !v.IsZero() {
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Int32:
+		// Runes
+		if v.Int() == 0 {
+			return "0"
+		}
+		return strconv.QuoteRune(rune(v.Int()))
+	case reflect.Uint8:
+		// Bytes e.g. the final byte of a sequence
+		if !stringer {
+			return strconv.FormatUint(v.Uint(), 10)
+		}
+		return strconv.QuoteRune(rune(v.Uint()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+
+	return fmt.Sprint(v.Interface())
+}
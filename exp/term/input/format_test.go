@@ -0,0 +1,56 @@
+package input
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestEventString(t *testing.T) {
+	cases := []struct {
+		e    Event
+		want string
+	}{
+		{nil, "nil"},
+		{KeyDownEvent{Rune: 'a', Mod: Ctrl | Alt, IsRepeat: true}, `KeyDownEvent{Rune: 'a', BaseRune: 0, AltRune: 0, ShiftedRune: 0, Text: "", Sym: 0, IsRepeat: true, RepeatCount: 0, Mod: ctrl+alt}`},
+		{KeyUpEvent{Sym: KeyKpEnter, Text: "\n", RepeatCount: 2}, `KeyUpEvent{Rune: 0, BaseRune: 0, AltRune: 0, ShiftedRune: 0, Text: "\n", Sym: kpenter, IsRepeat: false, RepeatCount: 2, Mod: 0}`},
+		{MouseDownEvent{X: 1, Y: 2, Button: MouseButtonLeft, Mod: Shift, Clicks: 2}, `MouseDownEvent{X: 1, Y: 2, DX: 0, DY: 0, Button: left, Mod: shift, Clicks: 2}`},
+		{MouseUpEvent{X: 3, Y: 4}, `MouseUpEvent{X: 3, Y: 4, DX: 0, DY: 0, Button: 0, Mod: 0, Clicks: 0}`},
+		{MouseMoveEvent{X: 5, Y: 6, DX: -1, DY: 1, Button: MouseButtonWheelUp}, `MouseMoveEvent{X: 5, Y: 6, DX: -1, DY: 1, Button: wheel up, Mod: 0, Clicks: 0}`},
+		{FocusEvent{}, `FocusEvent{}`},
+		{BlurEvent{}, `BlurEvent{}`},
+		{PasteStartEvent{}, `PasteStartEvent{}`},
+		{PasteEvent("hi\tthere"), `PasteEvent("hi\tthere")`},
+		{PasteEndEvent{}, `PasteEndEvent{}`},
+		{ForegroundColorEvent{color.RGBA{0xff, 0, 0x80, 0xff}}, `ForegroundColorEvent{Color: #ff0080}`},
+		{BackgroundColorEvent{}, `BackgroundColorEvent{Color: nil}`},
+		{CursorColorEvent{color.NRGBA{1, 2, 3, 4}}, `CursorColorEvent{Color: #01020304}`},
+		{PaletteColorEvent{Index: 3, Color: color.RGBA{0, 0xff, 0, 0xff}}, `PaletteColorEvent{Index: 3, Color: #00ff00}`},
+		{ClipboardEvent{Selection: 'c', Content: "abc"}, `ClipboardEvent{Selection: 'c', Content: "abc", Query: false}`},
+		{CursorPositionEvent{Row: 1, Col: 2}, `CursorPositionEvent{Row: 1, Col: 2}`},
+		{CursorStyleEvent{Style: 2, Blinking: true}, `CursorStyleEvent{Style: 2, Blinking: true}`},
+		{DcsDataEvent{Params: []byte("1"), Intermediates: []byte("+"), Final: 'r', Data: []byte("x")}, `DcsDataEvent{Params: "1", Intermediates: "+", Final: 'r', Data: "x"}`},
+		{WindowLabelEvent{Label: "w"}, `WindowLabelEvent{Label: "w"}`},
+		{IconLabelEvent{Label: "i"}, `IconLabelEvent{Label: "i"}`},
+		{KittyKeyboardEvent(3), `KittyKeyboardEvent(3)`},
+		{ModeReportEvent{Mode: 2026, Value: 2}, `ModeReportEvent{Mode: 2026, Value: 2, Private: false}`},
+		{ModifyOtherKeysEvent(2), `ModifyOtherKeysEvent(2)`},
+		{PrimaryDeviceAttributesEvent{62, 22}, `PrimaryDeviceAttributesEvent[62, 22]`},
+		{SecondaryDeviceAttributesEvent{1, 95, 0}, `SecondaryDeviceAttributesEvent[1, 95, 0]`},
+		{TermcapEvent{Values: map[string]string{"RGB": "", "Co": "256"}, IsValid: true}, `TermcapEvent{Values: {"Co": "256", "RGB": ""}, IsValid: true}`},
+		{TerminalVersionEvent{Name: "kitty(0.31)"}, `TerminalVersionEvent{Name: "kitty(0.31)"}`},
+		{WindowSizeEvent{Width: 80, Height: 24}, `WindowSizeEvent{Width: 80, Height: 24, PixelWidth: 0, PixelHeight: 0}`},
+		{UnknownEvent("\x1b"), `UnknownEvent("\x1b")`},
+		{UnknownCsiEvent("\x1b[?u"), `UnknownCsiEvent("\x1b[?u")`},
+		{UnknownSs3Event("\x1bOz"), `UnknownSs3Event("\x1bOz")`},
+		{UnknownOscEvent("\x1b]99\a"), `UnknownOscEvent("\x1b]99\a")`},
+		{UnknownDcsEvent("\x1bPq\x1b\\"), `UnknownDcsEvent("\x1bPq\x1b\\")`},
+		{UnknownApcEvent("\x1b_G\x1b\\"), `UnknownApcEvent("\x1b_G\x1b\\")`},
+		{MultiEvent{KeyDownEvent{Rune: 'a'}, MultiEvent{FocusEvent{}}}, `MultiEvent[KeyDownEvent{Rune: 'a', BaseRune: 0, AltRune: 0, ShiftedRune: 0, Text: "", Sym: 0, IsRepeat: false, RepeatCount: 0, Mod: 0}, MultiEvent[FocusEvent{}]]`},
+	}
+
+	for _, c := range cases {
+		if got := EventString(c.e); got != c.want {
+			t.Errorf("%#v:\nexpected %s\n     got %s", c.e, c.want, got)
+		}
+	}
+}
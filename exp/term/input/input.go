@@ -2,7 +2,6 @@ package input
 
 import (
 	"fmt"
-	"strings"
 )
 
 var (
@@ -11,6 +10,24 @@ var (
 
 	// ErrEmpty is returned when the event buffer is empty.
 	ErrEmpty = fmt.Errorf("empty event buffer")
+
+	// ErrInvalidKey is returned when a key string cannot be parsed.
+	ErrInvalidKey = fmt.Errorf("invalid key")
+
+	// ErrInvalidSequence is returned when a key sequence can't be registered.
+	ErrInvalidSequence = fmt.Errorf("invalid key sequence")
+
+	// ErrInvalidConfig is returned when a driver Config or the driver flags
+	// have contradictory options.
+	ErrInvalidConfig = fmt.Errorf("invalid config")
+
+	// ErrMixedRead is returned by ReadInput and PeekInput once the driver is
+	// read with ReadEvents.
+	ErrMixedRead = fmt.Errorf("input is read by ReadEvents")
+
+	// ErrTimeout is returned when the terminal doesn't respond to a query in
+	// time.
+	ErrTimeout = fmt.Errorf("timeout")
 )
 
 // Event represents a terminal input event.
@@ -21,17 +38,61 @@ type UnknownEvent string
 
 // String implements fmt.Stringer.
 func (e UnknownEvent) String() string {
-	return fmt.Sprintf("%q", string(e))
+	return EventString(e)
 }
 
-// WindowSizeEvent represents a window resize event.
+// IgnoredEvent is returned by ParseSequence for sequences that are consumed
+// but aren't reported e.g. a sequence cancelled by a CAN or SUB character.
+// The Driver and Parser never return it.
+type IgnoredEvent struct{}
+
+// WindowSizeEvent represents a window resize event. Width and Height are the
+// size of the window in cells. PixelWidth and PixelHeight are the size of the
+// window text area in pixels, and are zero when the terminal doesn't report
+// them.
+//
+// Terminals report the window size in-band with the in-band resize mode
+// (DECSET 2048) i.e. CSI 48 ; height ; width ; pixel-height ; pixel-width t,
+// and in response to a text area size request (XTWINOPS 18) i.e.
+// CSI 8 ; height ; width t. The Windows driver reports console buffer size
+// changes.
 type WindowSizeEvent struct {
-	Width, Height int
+	Width, Height           int
+	PixelWidth, PixelHeight int
 }
 
 // String implements fmt.Stringer.
 func (e WindowSizeEvent) String() string {
-	return fmt.Sprintf("resize: %dx%d", e.Width, e.Height)
+	return EventString(e)
+}
+
+// parseWindowReport parses the parameters of an XTWINOPS window size report.
+// It returns nil for other window reports and malformed reports.
+func parseWindowReport(params [][]uint) Event {
+	if len(params) == 0 {
+		return nil
+	}
+
+	switch params[0][0] {
+	case 8:
+		// CSI 8 ; height ; width t
+		if len(params) != 3 {
+			return nil
+		}
+		return WindowSizeEvent{Width: int(params[2][0]), Height: int(params[1][0])}
+	case 48:
+		// CSI 48 ; height ; width ; pixel-height ; pixel-width t
+		if len(params) != 5 {
+			return nil
+		}
+		return WindowSizeEvent{
+			Width:       int(params[2][0]),
+			Height:      int(params[1][0]),
+			PixelWidth:  int(params[4][0]),
+			PixelHeight: int(params[3][0]),
+		}
+	}
+	return nil
 }
 
 // MultiEvent represents multiple events.
@@ -39,9 +100,25 @@ type MultiEvent []Event
 
 // String implements fmt.Stringer.
 func (e MultiEvent) String() string {
-	var sb strings.Builder
-	for _, ev := range e {
-		sb.WriteString(fmt.Sprintf("%v\n", ev))
+	return EventString(e)
+}
+
+// FlattenEvents returns the event as a flat slice of events. MultiEvents are
+// expanded recursively in order, nil events and IgnoredEvents are dropped, and
+// any other event is returned as a single element slice.
+func FlattenEvents(e Event) []Event {
+	return appendFlatEvents(nil, e)
+}
+
+func appendFlatEvents(events []Event, e Event) []Event {
+	switch e := e.(type) {
+	case nil, IgnoredEvent:
+	case MultiEvent:
+		for _, ev := range e {
+			events = appendFlatEvents(events, ev)
+		}
+	default:
+		events = append(events, e)
 	}
-	return sb.String()
+	return events
 }
@@ -0,0 +1,87 @@
+package input
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestParseWindowSizeEvent(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+		str  string
+	}{
+		// Text area size in cells (XTWINOPS 18)
+		{"\x1b[8;24;80t", WindowSizeEvent{Width: 80, Height: 24}, "WindowSizeEvent{Width: 80, Height: 24, PixelWidth: 0, PixelHeight: 0}"},
+		// In-band resize (DECSET 2048)
+		{"\x1b[48;24;80;384;640t", WindowSizeEvent{Width: 80, Height: 24, PixelWidth: 640, PixelHeight: 384}, "WindowSizeEvent{Width: 80, Height: 24, PixelWidth: 640, PixelHeight: 384}"},
+		{"\x9b48;50;132;0;0t", WindowSizeEvent{Width: 132, Height: 50}, "WindowSizeEvent{Width: 132, Height: 50, PixelWidth: 0, PixelHeight: 0}"},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+			continue
+		}
+		if s := e.(WindowSizeEvent).String(); s != c.str {
+			t.Errorf("%q: expected string %q, got %q", c.seq, c.str, s)
+		}
+	}
+
+	for _, seq := range []string{
+		"\x1b[8;24t",
+		"\x1b[48;24;80t",
+		"\x1b[48;24;80;384t",
+		"\x1b[4;384;640t", // text area size in pixels
+		"\x1b[t",
+		"\x1b[?8;24;80t",
+	} {
+		if _, e := ParseSequence([]byte(seq)); !reflect.DeepEqual(e, UnknownCsiEvent(seq)) {
+			t.Errorf("%q: expected an unknown event, got %#v", seq, e)
+		}
+	}
+}
+
+func TestFlattenEvents(t *testing.T) {
+	a, b, c, d := KeyDownEvent{Rune: 'a'}, KeyDownEvent{Rune: 'b'}, KeyDownEvent{Rune: 'c'}, KeyDownEvent{Rune: 'd'}
+	cases := []struct {
+		name string
+		in   Event
+		want []Event
+	}{
+		{"nil", nil, nil},
+		{"single", a, []Event{a}},
+		{"empty", MultiEvent{}, nil},
+		{"flat", MultiEvent{a, b}, []Event{a, b}},
+		{"nested", MultiEvent{a, MultiEvent{b, c}, d}, []Event{a, b, c, d}},
+		{"deeply nested", MultiEvent{MultiEvent{MultiEvent{a}}, MultiEvent{}, b, MultiEvent{MultiEvent{c, d}}}, []Event{a, b, c, d}},
+		{"nil elements", MultiEvent{nil, a, MultiEvent{nil}, b}, []Event{a, b}},
+		{"duplicates", MultiEvent{a, MultiEvent{a, a}}, []Event{a, a, a}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := FlattenEvents(c.in); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestReadFlattensMultiEvents(t *testing.T) {
+	// Both colors are reported as separate events.
+	got := readEvents(t, 0, "\x1b]10;rgb:ffff/0000/0000;11;rgb:0000/0000/ffff\x07a")
+	want := []Event{
+		ForegroundColorEvent{color.RGBA{0xff, 0, 0, 0xff}},
+		BackgroundColorEvent{color.RGBA{0, 0, 0xff, 0xff}},
+		KeyDownEvent{Rune: 'a'},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
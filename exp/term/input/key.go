@@ -181,14 +181,56 @@ const (
 	KeyRightMeta
 	KeyIsoLevel3Shift
 	KeyIsoLevel5Shift
+
+	// KeyDead is a dead key, a key that doesn't produce a character on its
+	// own but modifies the next key e.g. ^ followed by e produces ê. The
+	// composed character is reported as a regular key event afterwards. Apps
+	// can use it to show the composition state.
+	KeyDead
+
+	// keySymCount is the number of key symbols, keep it last.
+	keySymCount
 )
 
 // key represents a key event.
 type key struct {
-	Rune     rune
-	AltRune  rune
+	Rune rune
+
+	// BaseRune is the key in the standard PC-101 layout when the terminal
+	// reports it, e.g. 'a' for the Cyrillic 'ф' key or 'y' for the 'z' key
+	// on a German layout. It's zero otherwise. Kitty reports this as the base
+	// layout key with ReportAlternateKeys.
+	//
+	// Keybindings that should refer to the physical key regardless of the
+	// layout and Shift should prefer BaseRune when it's set, and fall back to
+	// Rune otherwise.
+	BaseRune rune
+
+	// AltRune is the same as BaseRune.
+	//
+	// Deprecated: use BaseRune.
+	AltRune rune
+
+	// ShiftedRune is the rune the key produces with Shift, e.g. 'A' for 'a'
+	// or '!' for '1' on a US layout. It's only set when the terminal reports
+	// it and zero otherwise. Kitty reports this with ReportAlternateKeys.
+	ShiftedRune rune
+
+	// Text is the text the key produces when the terminal reports it, e.g.
+	// "A" for shift+a. Unlike Rune, which is always the base key, it reflects
+	// the modifiers and the keyboard layout. Kitty reports this with
+	// ReportAssociatedKeys, and it's empty otherwise.
+	Text string
+
 	Sym      KeySym
 	IsRepeat bool
+
+	// RepeatCount is the number of times the key was repeated when the
+	// terminal reports held down keys as a single event e.g. Windows Console.
+	// The driver reports each repeat as a separate event unless
+	// FlagCollapseRepeats is set.
+	RepeatCount int
+
 	Mod
 }
 
@@ -208,44 +250,52 @@ func (k KeyUpEvent) String() string {
 	return keyString(key(k))
 }
 
-// String implements fmt.Stringer.
+// keyString returns a human-readable representation of the key e.g.
+// "ctrl+shift+a". The modifier of a modifier key itself isn't reported, a
+// Left Ctrl key press with the Ctrl modifier is "leftctrl".
 func keyString(k key) string {
-	var s string
-	if k.Mod.IsCtrl() && k.Sym != KeyLeftCtrl && k.Sym != KeyRightCtrl {
-		s += "ctrl+"
-	}
-	if k.Mod.IsAlt() && k.Sym != KeyLeftAlt && k.Sym != KeyRightAlt {
-		s += "alt+"
-	}
-	if k.Mod.IsShift() && k.Sym != KeyLeftShift && k.Sym != KeyRightShift {
-		s += "shift+"
-	}
-	if k.Mod.IsMeta() && k.Sym != KeyLeftMeta && k.Sym != KeyRightMeta {
-		s += "meta+"
-	}
-	if k.Mod.IsHyper() && k.Sym != KeyLeftHyper && k.Sym != KeyRightHyper {
-		s += "hyper+"
-	}
-	if k.Mod.IsSuper() && k.Sym != KeyLeftSuper && k.Sym != KeyRightSuper {
-		s += "super+"
-	}
-	if k.Mod.IsCapsLock() && k.Sym != KeyCapsLock {
-		s += "capslock+"
-	}
-	if k.Mod.IsNumLock() && k.Sym != KeyNumLock {
-		s += "numlock+"
-	}
+	var name string
 	if k.Rune > ansi.US && k.Rune != ansi.DEL && utf8.ValidRune(k.Rune) {
 		// Space is the only invisible printable character.
 		if k.Rune == ' ' {
-			s += "space"
+			name = "space"
 		} else {
-			s += string(k.Rune)
+			name = string(k.Rune)
 		}
 	} else {
-		s += k.Sym.String()
+		name = k.Sym.String()
 	}
-	return s
+
+	mods := (k.Mod &^ keySymMod(k.Sym)).String()
+	if mods == "" {
+		return name
+	}
+	return mods + "+" + name
+}
+
+// keySymMod returns the modifier a modifier or lock key symbol represents.
+func keySymMod(sym KeySym) Mod {
+	switch sym {
+	case KeyLeftCtrl, KeyRightCtrl:
+		return Ctrl
+	case KeyLeftAlt, KeyRightAlt:
+		return Alt
+	case KeyLeftShift, KeyRightShift:
+		return Shift
+	case KeyLeftMeta, KeyRightMeta:
+		return Meta
+	case KeyLeftHyper, KeyRightHyper:
+		return Hyper
+	case KeyLeftSuper, KeyRightSuper:
+		return Super
+	case KeyCapsLock:
+		return CapsLock
+	case KeyNumLock:
+		return NumLock
+	case KeyScrollLock:
+		return ScrollLock
+	}
+	return 0
 }
 
 // String implements fmt.Stringer.
@@ -407,4 +457,5 @@ var keySymString = map[KeySym]string{
 	KeyRightMeta:        "rightmeta",
 	KeyIsoLevel3Shift:   "isolevel3shift",
 	KeyIsoLevel5Shift:   "isolevel5shift",
+	KeyDead:             "dead",
 }
@@ -0,0 +1,36 @@
+package input
+
+import "testing"
+
+func TestKeySymString(t *testing.T) {
+	names := make(map[string]KeySym)
+	for sym := KeyNone + 1; sym < keySymCount; sym++ {
+		name := sym.String()
+		if _, ok := keySymString[sym]; !ok {
+			t.Errorf("%d: expected a name", sym)
+			continue
+		}
+		if other, ok := names[name]; ok {
+			t.Errorf("%d: name %q is already used by %d", sym, name, other)
+		}
+		names[name] = sym
+	}
+
+	for _, c := range []struct {
+		sym  KeySym
+		want string
+	}{
+		{KeyNone, "unknown"},
+		{keySymCount, "unknown"},
+		{KeyUp, "up"},
+		{KeyF24, "f24"},
+		{KeyKpEnter, "kpenter"},
+		{KeyBegin, "begin"},
+		{KeyPrintScreen, "printscreen"},
+		{KeyRightSuper, "rightsuper"},
+	} {
+		if got := c.sym.String(); got != c.want {
+			t.Errorf("%d: expected %q, got %q", c.sym, c.want, got)
+		}
+	}
+}
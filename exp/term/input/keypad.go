@@ -0,0 +1,73 @@
+package input
+
+// keypadKeys maps the keypad key symbols to their main keys. Digits and
+// operators map to their runes, and the navigation keys to their symbols.
+var keypadKeys = map[KeySym]key{
+	KeyKpEnter:  {Sym: KeyEnter},
+	KeyKpEqual:  {Rune: '='},
+	KeyKpMul:    {Rune: '*'},
+	KeyKpPlus:   {Rune: '+'},
+	KeyKpComma:  {Rune: ','},
+	KeyKpMinus:  {Rune: '-'},
+	KeyKpPeriod: {Rune: '.'},
+	KeyKpDiv:    {Rune: '/'},
+	KeyKp0:      {Rune: '0'},
+	KeyKp1:      {Rune: '1'},
+	KeyKp2:      {Rune: '2'},
+	KeyKp3:      {Rune: '3'},
+	KeyKp4:      {Rune: '4'},
+	KeyKp5:      {Rune: '5'},
+	KeyKp6:      {Rune: '6'},
+	KeyKp7:      {Rune: '7'},
+	KeyKp8:      {Rune: '8'},
+	KeyKp9:      {Rune: '9'},
+	KeyKpSep:    {Rune: ','},
+	KeyKpUp:     {Sym: KeyUp},
+	KeyKpDown:   {Sym: KeyDown},
+	KeyKpLeft:   {Sym: KeyLeft},
+	KeyKpRight:  {Sym: KeyRight},
+	KeyKpPgUp:   {Sym: KeyPgUp},
+	KeyKpPgDown: {Sym: KeyPgDown},
+	KeyKpHome:   {Sym: KeyHome},
+	KeyKpEnd:    {Sym: KeyEnd},
+	KeyKpInsert: {Sym: KeyInsert},
+	KeyKpDelete: {Sym: KeyDelete},
+	KeyKpBegin:  {Sym: KeyBegin},
+}
+
+// IsKeypad reports whether the key is on the numeric keypad.
+func (k KeyDownEvent) IsKeypad() bool {
+	_, ok := keypadKeys[k.Sym]
+	return ok
+}
+
+// IsKeypad reports whether the key is on the numeric keypad.
+func (k KeyUpEvent) IsKeypad() bool {
+	_, ok := keypadKeys[k.Sym]
+	return ok
+}
+
+// Normalize returns the key with keypad keys folded to their main keys for
+// apps that don't distinguish the numeric keypad. The keypad digits and
+// operators are reported as their runes e.g. KeyKp5 is '5', KeyKpMul is '*',
+// and KeyKpSep is ','. KeyKpEnter is KeyEnter, and the keypad navigation keys
+// are their main keys e.g. KeyKpUp is KeyUp. The modifiers and the repeat
+// state are kept. Other keys are returned as is.
+func (k KeyDownEvent) Normalize() KeyDownEvent {
+	return KeyDownEvent(normalizeKeypad(key(k)))
+}
+
+// Normalize returns the key with keypad keys folded to their main keys. See
+// KeyDownEvent.Normalize.
+func (k KeyUpEvent) Normalize() KeyUpEvent {
+	return KeyUpEvent(normalizeKeypad(key(k)))
+}
+
+func normalizeKeypad(k key) key {
+	m, ok := keypadKeys[k.Sym]
+	if !ok {
+		return k
+	}
+	k.Sym, k.Rune = m.Sym, m.Rune
+	return k
+}
@@ -0,0 +1,69 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeypadNormalize(t *testing.T) {
+	cases := []struct {
+		key  KeyDownEvent
+		want KeyDownEvent
+	}{
+		{KeyDownEvent{Sym: KeyKpEnter}, KeyDownEvent{Sym: KeyEnter}},
+		{KeyDownEvent{Sym: KeyKp0}, KeyDownEvent{Rune: '0'}},
+		{KeyDownEvent{Sym: KeyKp5, Mod: Ctrl}, KeyDownEvent{Rune: '5', Mod: Ctrl}},
+		{KeyDownEvent{Sym: KeyKp9, IsRepeat: true}, KeyDownEvent{Rune: '9', IsRepeat: true}},
+		{KeyDownEvent{Sym: KeyKpMul}, KeyDownEvent{Rune: '*'}},
+		{KeyDownEvent{Sym: KeyKpDiv}, KeyDownEvent{Rune: '/'}},
+		{KeyDownEvent{Sym: KeyKpPlus}, KeyDownEvent{Rune: '+'}},
+		{KeyDownEvent{Sym: KeyKpMinus}, KeyDownEvent{Rune: '-'}},
+		{KeyDownEvent{Sym: KeyKpPeriod}, KeyDownEvent{Rune: '.'}},
+		{KeyDownEvent{Sym: KeyKpEqual}, KeyDownEvent{Rune: '='}},
+		{KeyDownEvent{Sym: KeyKpUp, Mod: Shift}, KeyDownEvent{Sym: KeyUp, Mod: Shift}},
+		{KeyDownEvent{Sym: KeyKpBegin}, KeyDownEvent{Sym: KeyBegin}},
+		{KeyDownEvent{Sym: KeyKpDelete}, KeyDownEvent{Sym: KeyDelete}},
+
+		// Main keys are kept as is.
+		{KeyDownEvent{Rune: '5'}, KeyDownEvent{Rune: '5'}},
+		{KeyDownEvent{Sym: KeyEnter}, KeyDownEvent{Sym: KeyEnter}},
+		{KeyDownEvent{Sym: KeyF1, Mod: Alt}, KeyDownEvent{Sym: KeyF1, Mod: Alt}},
+	}
+
+	for _, c := range cases {
+		if got := c.key.Normalize(); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%v: expected %#v, got %#v", c.key, c.want, got)
+		}
+		if got, want := KeyUpEvent(c.key).Normalize(), KeyUpEvent(c.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: expected key up %#v, got %#v", c.key, want, got)
+		}
+	}
+}
+
+func TestKeypadIsKeypad(t *testing.T) {
+	for sym := KeyNone; sym < keySymCount; sym++ {
+		k := KeyDownEvent{Sym: sym}
+		want := sym >= KeyKpEnter && sym <= KeyKp9 || sym >= KeyKpSep && sym <= KeyKpBegin
+		if got := k.IsKeypad(); got != want {
+			t.Errorf("%v: expected %v, got %v", sym, want, got)
+		}
+		if got := KeyUpEvent(k).IsKeypad(); got != want {
+			t.Errorf("%v: expected key up %v, got %v", sym, want, got)
+		}
+		if want && k.Normalize().IsKeypad() {
+			t.Errorf("%v: expected the normalized key not to be a keypad key", sym)
+		}
+	}
+
+	// The keypad keys decoded from the input can be matched as main keys.
+	for _, in := range []string{"\x1bOp", "\x1b[57399u"} {
+		events := DecodeString(in)
+		if len(events) != 1 {
+			t.Fatalf("%q: expected one event, got %v", in, events)
+		}
+		k, ok := events[0].(KeyDownEvent)
+		if !ok || !k.IsKeypad() || k.Normalize() != (KeyDownEvent{Rune: '0'}) {
+			t.Errorf("%q: expected keypad 0, got %#v", in, events[0])
+		}
+	}
+}
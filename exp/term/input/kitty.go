@@ -36,34 +36,22 @@ func (e KittyKeyboardEvent) IsReportAssociatedKeys() bool {
 
 // String implements fmt.Stringer.
 func (e KittyKeyboardEvent) String() string {
-	s := "Flags:"
-	if e == 0 {
-		return s + " none"
-	}
-	if e.IsDisambiguateEscapeCodes() {
-		s += " DisambiguateEscapeCodes"
-	}
-	if e.IsReportEventTypes() {
-		s += " ReportEventTypes"
-	}
-	if e.IsReportAlternateKeys() {
-		s += " ReportAlternateKeys"
-	}
-	if e.IsReportAllKeys() {
-		s += " ReportAllKeys"
-	}
-	if e.IsReportAssociatedKeys() {
-		s += " ReportAssociatedKeys"
-	}
-	return s
+	return EventString(e)
 }
 
-// Kitty Clipboard Control Sequences
+// kittyKeyMap maps Kitty functional key codes to key symbols.
+//
+// Kitty reports lock keys (CapsLock, ScrollLock, and NumLock) as regular key
+// press and release events when the ReportAllKeys flag is set. These are
+// different from the lock modifiers, which only tell whether a lock is ON at
+// the time of the event. Compare with the Windows Console API, where we only
+// get the lock ON states and use them to suppress spurious events.
 var kittyKeyMap = map[int]KeySym{
 	ansi.BS:  KeyBackspace,
 	ansi.HT:  KeyTab,
 	ansi.CR:  KeyEnter,
 	ansi.ESC: KeyEscape,
+	ansi.SP:  KeySpace,
 	ansi.DEL: KeyBackspace,
 
 	57344: KeyEscape,
@@ -80,9 +68,9 @@ var kittyKeyMap = map[int]KeySym{
 	57355: KeyPgDown,
 	57356: KeyHome,
 	57357: KeyEnd,
-	57358: KeyCapsLock,
-	57359: KeyScrollLock,
-	57360: KeyNumLock,
+	57358: KeyCapsLock,   // CSI 57358 u
+	57359: KeyScrollLock, // CSI 57359 u
+	57360: KeyNumLock,    // CSI 57360 u
 	57361: KeyPrintScreen,
 	57362: KeyPause,
 	57363: KeyMenu,
@@ -226,16 +214,28 @@ func parseKittyKeyboard(params [][]uint) Event {
 		code := int(params[0][0])
 		if sym, ok := kittyKeyMap[code]; ok {
 			key.Sym = sym
+			if sym == KeySpace {
+				// Report space the same way as legacy terminals.
+				key.Rune = ' '
+			}
 		} else {
 			r := rune(code)
 			if !utf8.ValidRune(r) {
 				r = utf8.RuneError
 			}
 			key.Rune = r
+
+			// CSI unicode-key-code:shifted-key:base-layout-key
+			// Either of the alternate keys might be empty.
 			if len(params[0]) > 1 {
-				al := rune(params[0][1])
-				if utf8.ValidRune(al) {
-					key.AltRune = al
+				if sr := rune(params[0][1]); sr != 0 && utf8.ValidRune(sr) {
+					key.ShiftedRune = sr
+				}
+			}
+			if len(params[0]) > 2 {
+				if br := rune(params[0][2]); br != 0 && utf8.ValidRune(br) {
+					key.BaseRune = br
+					key.AltRune = br
 				}
 			}
 		}
@@ -255,11 +255,20 @@ func parseKittyKeyboard(params [][]uint) Event {
 		}
 	}
 	if len(params) > 2 {
-		r := rune(params[2][0])
-		if !utf8.ValidRune(r) {
-			r = utf8.RuneError
+		// CSI ... ; text-as-codepoints u
+		// The associated text codepoints are colon separated.
+		var text []rune
+		for _, c := range params[2] {
+			r := rune(c)
+			if r == 0 {
+				continue
+			}
+			if !utf8.ValidRune(r) {
+				r = utf8.RuneError
+			}
+			text = append(text, r)
 		}
-		key.AltRune = r
+		key.Text = string(text)
 	}
 	if isRelease {
 		return KeyUpEvent(key)
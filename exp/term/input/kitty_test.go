@@ -0,0 +1,147 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/x/exp/term/ansi"
+)
+
+func TestParseKittyLockKeys(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		{"\x1b[57358u", KeyDownEvent{Sym: KeyCapsLock}},
+		{"\x1b[57358;1:3u", KeyUpEvent{Sym: KeyCapsLock}},
+		{"\x1b[57358;65u", KeyDownEvent{Sym: KeyCapsLock, Mod: CapsLock}},
+		{"\x1b[57358;65:3u", KeyUpEvent{Sym: KeyCapsLock, Mod: CapsLock}},
+		{"\x1b[57360u", KeyDownEvent{Sym: KeyNumLock}},
+		{"\x1b[57360;1:3u", KeyUpEvent{Sym: KeyNumLock}},
+		{"\x1b[57360;129u", KeyDownEvent{Sym: KeyNumLock, Mod: NumLock}},
+		{"\x1b[57360;129:2u", KeyDownEvent{Sym: KeyNumLock, Mod: NumLock, IsRepeat: true}},
+		{"\x1b[57359u", KeyDownEvent{Sym: KeyScrollLock}},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+}
+
+func TestParseKittyKeyboard(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		// Press, repeat, and release
+		{"\x1b[97u", KeyDownEvent{Rune: 'a'}},
+		{"\x1b[97;1:1u", KeyDownEvent{Rune: 'a'}},
+		{"\x1b[97;1:2u", KeyDownEvent{Rune: 'a', IsRepeat: true}},
+		{"\x1b[97;1:3u", KeyUpEvent{Rune: 'a'}},
+
+		// Modifiers use the same +1 offset as XTerm
+		{"\x1b[97;2u", KeyDownEvent{Rune: 'a', Mod: Shift}},
+		{"\x1b[97;3u", KeyDownEvent{Rune: 'a', Mod: Alt}},
+		{"\x1b[97;5u", KeyDownEvent{Rune: 'a', Mod: Ctrl}},
+		{"\x1b[97;7:3u", KeyUpEvent{Rune: 'a', Mod: Alt | Ctrl}},
+		{"\x1b[97;9u", KeyDownEvent{Rune: 'a', Mod: Super}},
+
+		// Legacy keys
+		{"\x1b[13u", KeyDownEvent{Sym: KeyEnter}},
+		{"\x1b[9;2u", KeyDownEvent{Sym: KeyTab, Mod: Shift}},
+		{"\x1b[27u", KeyDownEvent{Sym: KeyEscape}},
+		{"\x1b[127;5u", KeyDownEvent{Sym: KeyBackspace, Mod: Ctrl}},
+		{"\x1b[32u", KeyDownEvent{Sym: KeySpace, Rune: ' '}},
+		{"\x1b[32;5u", KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl}},
+
+		// Functional keys in the private use area
+		{"\x1b[57366u", KeyDownEvent{Sym: KeyF3}},
+		{"\x1b[57352u", KeyDownEvent{Sym: KeyUp}},
+		{"\x1b[57376;1:3u", KeyUpEvent{Sym: KeyF13}},
+
+		// Keypad keys
+		{"\x1b[57399u", KeyDownEvent{Sym: KeyKp0}},
+		{"\x1b[57408;5u", KeyDownEvent{Sym: KeyKp9, Mod: Ctrl}},
+		{"\x1b[57409u", KeyDownEvent{Sym: KeyKpPeriod}},
+
+		// Shifted key and text
+		{"\x1b[97:65;2u", KeyDownEvent{Rune: 'a', ShiftedRune: 'A', Mod: Shift}},
+		{"\x1b[97:65:97;2u", KeyDownEvent{Rune: 'a', ShiftedRune: 'A', BaseRune: 'a', AltRune: 'a', Mod: Shift}},
+		{"\x1b[1092:1060:97;2;1060u", KeyDownEvent{Rune: 'ф', ShiftedRune: 'Ф', BaseRune: 'a', AltRune: 'a', Text: "Ф", Mod: Shift}},
+		{"\x1b[1092::97u", KeyDownEvent{Rune: 'ф', BaseRune: 'a', AltRune: 'a'}},
+		{"\x1b[1092u", KeyDownEvent{Rune: 'ф'}},
+
+		// German layout: z is on the US y key, shift+7 is /.
+		{"\x1b[122::121u", KeyDownEvent{Rune: 'z', BaseRune: 'y', AltRune: 'y'}},
+		{"\x1b[55:47:55;2u", KeyDownEvent{Rune: '7', ShiftedRune: '/', BaseRune: '7', AltRune: '7', Mod: Shift}},
+		{"\x1b[252:220:91;2;220u", KeyDownEvent{Rune: 'ü', ShiftedRune: 'Ü', BaseRune: '[', AltRune: '[', Text: "Ü", Mod: Shift}},
+
+		// French layout: a is on the US q key, the & key is the US 1 key
+		// and produces 1 with shift.
+		{"\x1b[97::113u", KeyDownEvent{Rune: 'a', BaseRune: 'q', AltRune: 'q'}},
+		{"\x1b[38:49:49;2;49u", KeyDownEvent{Rune: '&', ShiftedRune: '1', BaseRune: '1', AltRune: '1', Text: "1", Mod: Shift}},
+		{"\x1b[233:201:50u", KeyDownEvent{Rune: 'é', ShiftedRune: 'É', BaseRune: '2', AltRune: '2'}},
+		{"\x1b[97;2;65u", KeyDownEvent{Rune: 'a', Text: "A", Mod: Shift}},
+		{"\x1b[49:33;2;33u", KeyDownEvent{Rune: '1', ShiftedRune: '!', Text: "!", Mod: Shift}},
+		{"\x1b[97;1;97:769u", KeyDownEvent{Rune: 'a', Text: "a\u0301"}},
+		{"\x1b[97;1:3u", KeyUpEvent{Rune: 'a'}},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+}
+
+func TestParseKittyKeyboardFlags(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+		str  string
+	}{
+		{"\x1b[?0u", KittyKeyboardEvent(0), "KittyKeyboardEvent(0)"},
+		{"\x1b[?1u", KittyKeyboardEvent(ansi.KittyDisambiguateEscapeCodes), "KittyKeyboardEvent(1)"},
+		{"\x1b[?3u", KittyKeyboardEvent(ansi.KittyDisambiguateEscapeCodes | ansi.KittyReportEventTypes), "KittyKeyboardEvent(3)"},
+		{"\x1b[?8u", KittyKeyboardEvent(ansi.KittyReportAllKeys), "KittyKeyboardEvent(8)"},
+		{"\x1b[?31u", KittyKeyboardEvent(ansi.KittyAllFlags), "KittyKeyboardEvent(31)"},
+		{"\x9b?5u", KittyKeyboardEvent(ansi.KittyDisambiguateEscapeCodes | ansi.KittyReportAlternateKeys), "KittyKeyboardEvent(5)"},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+			continue
+		}
+		if s := e.(KittyKeyboardEvent).String(); s != c.str {
+			t.Errorf("%q: expected string %q, got %q", c.seq, c.str, s)
+		}
+	}
+
+	// Replies without exactly one flags parameter are malformed.
+	for _, seq := range []string{"\x1b[?u", "\x1b[?1;2u", "\x1b[?1:2u", "\x1b[??1u", "\x1b[?;u"} {
+		if _, e := ParseSequence([]byte(seq)); !reflect.DeepEqual(e, UnknownCsiEvent(seq)) {
+			t.Errorf("%q: expected an unknown event, got %#v", seq, e)
+		}
+	}
+
+	// The reply isn't a key.
+	e := DecodeString("\x1b[?1u\x1b[97u")
+	if want := []Event{KittyKeyboardEvent(ansi.KittyDisambiguateEscapeCodes), KeyDownEvent{Rune: 'a'}}; !reflect.DeepEqual(e, want) {
+		t.Errorf("expected %v, got %v", want, e)
+	}
+}
@@ -65,3 +65,43 @@ func (m Mod) IsNumLock() bool {
 func (m Mod) IsScrollLock() bool {
 	return m&ScrollLock != 0
 }
+
+// Contains reports whether all the modifiers in mods are set. It reports true
+// when mods is zero.
+func (m Mod) Contains(mods Mod) bool {
+	return m&mods == mods
+}
+
+// modStrings are the modifier names in the order they're reported by
+// Mod.String.
+var modStrings = []struct {
+	mod  Mod
+	name string
+}{
+	{Ctrl, "ctrl"},
+	{Alt, "alt"},
+	{Shift, "shift"},
+	{Meta, "meta"},
+	{Hyper, "hyper"},
+	{Super, "super"},
+	{CapsLock, "capslock"},
+	{NumLock, "numlock"},
+	{ScrollLock, "scrolllock"},
+}
+
+// String implements fmt.Stringer. It returns the modifier names joined with
+// "+" in a fixed order i.e. ctrl, alt, shift, meta, hyper, super, capslock,
+// numlock, and scrolllock. It returns an empty string when no modifiers are
+// set.
+func (m Mod) String() string {
+	var s string
+	for _, n := range modStrings {
+		if m&n.mod != 0 {
+			if s != "" {
+				s += "+"
+			}
+			s += n.name
+		}
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package input
+
+import "testing"
+
+func TestModString(t *testing.T) {
+	cases := []struct {
+		mod  Mod
+		want string
+	}{
+		{0, ""},
+		{Ctrl, "ctrl"},
+		{Shift | Ctrl, "ctrl+shift"},
+		{Shift | Alt | Ctrl, "ctrl+alt+shift"},
+		{Super | Meta | Ctrl, "ctrl+meta+super"},
+		{Hyper | Super | Meta | Shift | Alt | Ctrl, "ctrl+alt+shift+meta+hyper+super"},
+		{CapsLock | NumLock | ScrollLock, "capslock+numlock+scrolllock"},
+	}
+	for _, c := range cases {
+		if got := c.mod.String(); got != c.want {
+			t.Errorf("%d: expected %q, got %q", c.mod, c.want, got)
+		}
+	}
+}
+
+func TestModPredicates(t *testing.T) {
+	preds := []struct {
+		mod Mod
+		is  func(Mod) bool
+	}{
+		{Shift, Mod.IsShift},
+		{Alt, Mod.IsAlt},
+		{Ctrl, Mod.IsCtrl},
+		{Meta, Mod.IsMeta},
+		{Hyper, Mod.IsHyper},
+		{Super, Mod.IsSuper},
+		{CapsLock, Mod.IsCapsLock},
+		{NumLock, Mod.IsNumLock},
+		{ScrollLock, Mod.IsScrollLock},
+	}
+
+	// Every single modifier and every pair of modifiers.
+	for _, a := range preds {
+		for _, b := range preds {
+			m := a.mod | b.mod
+			for _, p := range preds {
+				want := p.mod == a.mod || p.mod == b.mod
+				if got := p.is(m); got != want {
+					t.Errorf("%s: expected %s to be %v, got %v", m, p.mod, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestModContains(t *testing.T) {
+	cases := []struct {
+		mod  Mod
+		mods Mod
+		want bool
+	}{
+		{0, 0, true},
+		{Ctrl, 0, true},
+		{0, Ctrl, false},
+		{Ctrl, Ctrl, true},
+		{Ctrl | Alt, Ctrl, true},
+		{Ctrl | Alt, Ctrl | Alt, true},
+		{Ctrl, Ctrl | Alt, false},
+		{Meta | Super, Super, true},
+		{Meta | Super, Hyper, false},
+		{Shift | CapsLock, Shift | CapsLock | NumLock, false},
+	}
+	for _, c := range cases {
+		if got := c.mod.Contains(c.mods); got != c.want {
+			t.Errorf("%q contains %q: expected %v, got %v", c.mod, c.mods, c.want, got)
+		}
+	}
+}
+
+func TestKeyString(t *testing.T) {
+	cases := []struct {
+		key  KeyDownEvent
+		want string
+	}{
+		{KeyDownEvent{Rune: 'a'}, "a"},
+		{KeyDownEvent{Rune: 'a', Mod: Shift | Ctrl}, "ctrl+shift+a"},
+		{KeyDownEvent{Rune: 'A', Mod: Alt}, "alt+A"},
+		{KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl}, "ctrl+space"},
+		{KeyDownEvent{Sym: KeyEnter}, "enter"},
+		{KeyDownEvent{Sym: KeyTab, Mod: Shift}, "shift+tab"},
+		{KeyDownEvent{Sym: KeyUp, Mod: Ctrl | Alt}, "ctrl+alt+up"},
+		{KeyDownEvent{Sym: KeyLeftCtrl, Mod: Ctrl}, "leftctrl"},
+		{KeyDownEvent{Sym: KeyRightAlt, Mod: Alt | Shift}, "shift+rightalt"},
+		{KeyDownEvent{Sym: KeyCapsLock, Mod: CapsLock}, "capslock"},
+		{KeyDownEvent{Sym: KeyNumLock, Mod: NumLock}, "numlock"},
+		{KeyDownEvent{Sym: KeyScrollLock, Mod: ScrollLock}, "scrolllock"},
+		{KeyDownEvent{Rune: 'a', Mod: Super | Meta}, "meta+super+a"},
+		{KeyDownEvent{Sym: KeyLeft, Mod: Super | Ctrl}, "ctrl+super+left"},
+	}
+	for _, c := range cases {
+		if got := c.key.String(); got != c.want {
+			t.Errorf("%#v: expected %q, got %q", c.key, c.want, got)
+		}
+		if got := KeyUpEvent(c.key).String(); got != c.want {
+			t.Errorf("%#v: expected key up %q, got %q", c.key, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package input
+
+// ModeReportEvent represents a report mode event for sequence DECRPM.
+// Terminals send this in response to a DECRQM request.
+//
+//	CSI ? Pd ; Ps $ y  (DEC private modes)
+//	CSI Pa ; Ps $ y    (ANSI standard modes)
+//
+// See: https://vt100.net/docs/vt510-rm/DECRPM.html
+type ModeReportEvent struct {
+	// Mode is the mode number.
+	Mode int
+
+	// Value is the mode state, one of the Mode* state values e.g. ModeSet.
+	Value int
+
+	// Private reports whether the mode is a DEC private mode. Private and
+	// standard modes share numbers, mode 4 is IRM while mode ?4 is DECSCLM.
+	Private bool
+}
+
+// The mode states reported in ModeReportEvent.Value.
+const (
+	ModeNotRecognized = iota
+	ModeSet
+	ModeReset
+	ModePermanentlySet
+	ModePermanentlyReset
+)
+
+// IsRecognized reports whether the terminal recognizes the mode. Apps can use
+// this to detect support for a mode e.g. synchronized output (?2026).
+func (e ModeReportEvent) IsRecognized() bool {
+	return e.Value != ModeNotRecognized
+}
+
+// IsSet reports whether the mode is set or permanently set.
+func (e ModeReportEvent) IsSet() bool {
+	return e.Value == ModeSet || e.Value == ModePermanentlySet
+}
+
+// IsReset reports whether the mode is reset or permanently reset.
+func (e ModeReportEvent) IsReset() bool {
+	return e.Value == ModeReset || e.Value == ModePermanentlyReset
+}
+
+// IsPermanent reports whether the mode is permanently set or reset and can't
+// be changed.
+func (e ModeReportEvent) IsPermanent() bool {
+	return e.Value == ModePermanentlySet || e.Value == ModePermanentlyReset
+}
+
+// String implements fmt.Stringer.
+func (e ModeReportEvent) String() string {
+	return EventString(e)
+}
+
+func parseModeReport(params [][]uint, private bool) Event {
+	return ModeReportEvent{
+		Mode:    int(params[0][0]),
+		Value:   int(params[1][0]),
+		Private: private,
+	}
+}
@@ -0,0 +1,80 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseModeReport(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		{"\x1b[4;1$y", ModeReportEvent{Mode: 4, Value: 1}},
+		{"\x1b[20;2$y", ModeReportEvent{Mode: 20, Value: 2}},
+		{"\x1b[?1006;1$y", ModeReportEvent{Mode: 1006, Value: 1, Private: true}},
+		{"\x1b[?4;2$y", ModeReportEvent{Mode: 4, Value: 2, Private: true}},
+		{"\x1b[?2026;2$y", ModeReportEvent{Mode: 2026, Value: 2, Private: true}},
+		{"\x1b[?2026;0$y", ModeReportEvent{Mode: 2026, Value: 0, Private: true}},
+		{"\x1b[?2004;1$y", ModeReportEvent{Mode: 2004, Value: ModeSet, Private: true}},
+		{"\x1b[?2004;2$y", ModeReportEvent{Mode: 2004, Value: ModeReset, Private: true}},
+		{"\x1b[?1004;1$y", ModeReportEvent{Mode: 1004, Value: ModeSet, Private: true}},
+		{"\x1b[?1004;0$y", ModeReportEvent{Mode: 1004, Value: ModeNotRecognized, Private: true}},
+		{"\x1b[?1049;2$y", ModeReportEvent{Mode: 1049, Value: ModeReset, Private: true}},
+		{"\x1b[?1049;4$y", ModeReportEvent{Mode: 1049, Value: ModePermanentlyReset, Private: true}},
+		{"\x9b?2004;3$y", ModeReportEvent{Mode: 2004, Value: ModePermanentlySet, Private: true}},
+		{"\x1b[?1006$y", UnknownCsiEvent("\x1b[?1006$y")},
+		{"\x1b[4;1y", UnknownCsiEvent("\x1b[4;1y")},
+	}
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected length %d, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+}
+
+func TestModeReportValues(t *testing.T) {
+	cases := []struct {
+		value                               int
+		recognized, set, reset, isPermanent bool
+	}{
+		{ModeNotRecognized, false, false, false, false},
+		{ModeSet, true, true, false, false},
+		{ModeReset, true, false, true, false},
+		{ModePermanentlySet, true, true, false, true},
+		{ModePermanentlyReset, true, false, true, true},
+	}
+	for _, c := range cases {
+		e := ModeReportEvent{Mode: 2026, Value: c.value, Private: true}
+		if e.IsRecognized() != c.recognized || e.IsSet() != c.set ||
+			e.IsReset() != c.reset || e.IsPermanent() != c.isPermanent {
+			t.Errorf("value %d: got recognized=%v set=%v reset=%v permanent=%v", c.value,
+				e.IsRecognized(), e.IsSet(), e.IsReset(), e.IsPermanent())
+		}
+	}
+
+	// Synchronized output supported but currently reset.
+	_, e := ParseSequence([]byte("\x1b[?2026;2$y"))
+	if m, ok := e.(ModeReportEvent); !ok || !m.IsRecognized() || !m.IsReset() {
+		t.Errorf("expected a supported and reset mode ?2026, got %#v", e)
+	}
+}
+
+func TestModeReportCommonModes(t *testing.T) {
+	// Apps query the modes they enable to detect whether they're active e.g.
+	// bracketed paste (?2004), focus reporting (?1004), and the alternate
+	// screen (?1049).
+	in := "\x1b[?2004;1$y\x1b[?1004;2$y\x1b[?1049;0$y"
+	want := []Event{
+		ModeReportEvent{Mode: 2004, Value: ModeSet, Private: true},
+		ModeReportEvent{Mode: 1004, Value: ModeReset, Private: true},
+		ModeReportEvent{Mode: 1049, Value: ModeNotRecognized, Private: true},
+	}
+	if got := DecodeString(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
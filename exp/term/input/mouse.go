@@ -1,8 +1,9 @@
 package input
 
 import (
-	"regexp"
-	"strconv"
+	"math"
+
+	"github.com/charmbracelet/x/exp/term/ansi"
 )
 
 // MouseButton represents the button that was pressed during a mouse event.
@@ -21,10 +22,14 @@ type MouseButton int
 //	7 = push scroll wheel right
 //	8 = 4th button (aka browser backward button)
 //	9 = 5th button (aka browser forward button)
-//	10
-//	11
+//	10 = 6th button
+//	11 = 7th button
 //
-// Other buttons are not supported.
+// Buttons 8-11 are reported with the additional buttons bit (128) set, i.e.
+// the button codes 128-131. There's no convention for the 6th and 7th
+// buttons, they're mapped to whatever the mouse driver chooses e.g. the
+// Linux evdev BTN_FORWARD and BTN_BACK side buttons. Other buttons are not
+// supported.
 const (
 	MouseButtonNone MouseButton = iota
 	MouseButtonLeft
@@ -55,11 +60,31 @@ var mouseButtons = map[MouseButton]string{
 	MouseButton11:         "button 11",
 }
 
+// String implements fmt.Stringer.
+func (b MouseButton) String() string {
+	if s, ok := mouseButtons[b]; ok {
+		return s
+	}
+	return "unknown"
+}
+
 // mouse represents a mouse event.
 type mouse struct {
-	X, Y   int
+	X, Y int
+
+	// DX and DY are the distance the mouse moved since the last mouse event.
+	// They are only reported when the driver has FlagMouseDelta set, and are
+	// reset to zero on button press events.
+	DX, DY int
+
 	Button MouseButton
 	Mod
+
+	// Clicks is the number of consecutive presses of the same button in the
+	// same cell i.e. 1 for a single click, 2 for a double-click, and 3 for a
+	// triple-click. It's only reported for press events when the driver has
+	// FlagMouseClicks set, otherwise it's zero.
+	Clicks int
 }
 
 // IsWheel returns true if the mouse event is a wheel event.
@@ -71,23 +96,15 @@ func isWheel(b MouseButton) bool {
 	return b >= MouseButtonWheelUp && b <= MouseButtonWheelRight
 }
 
-// String implements fmt.Stringer.
+// String implements fmt.Stringer. The modifiers are reported in the same
+// order as Mod.String followed by the button e.g. "ctrl+alt+super+left".
 func (m mouse) String() (s string) {
-	if m.Mod.IsCtrl() {
-		s += "ctrl+"
-	}
-	if m.Mod.IsAlt() {
-		s += "alt+"
-	}
-	if m.Mod.IsShift() {
-		s += "shift+"
+	if mods := m.Mod.String(); mods != "" {
+		s = mods + "+"
 	}
 
-	str, ok := mouseButtons[m.Button]
-	if !ok {
-		s += "unknown"
-	} else if str != "none" { // motion events don't have a button
-		s += str
+	if m.Button != MouseButtonNone { // motion events don't have a button
+		s += m.Button.String()
 	}
 
 	return s
@@ -138,7 +155,37 @@ func (m MouseMoveEvent) String() (s string) {
 	return mm.String()
 }
 
-var mouseSGRRegex = regexp.MustCompile(`(\d+);(\d+);(\d+)([Mm])`)
+// ParseMouse decodes a single complete SGR or X10 mouse sequence. It detects
+// the encoding from the sequence prefix and returns the mouse event and true,
+// or nil and false if p is not a mouse sequence.
+//
+// Both 7-bit (ESC [) and 8-bit (CSI) introducers are supported.
+func ParseMouse(p []byte) (Event, bool) {
+	switch {
+	case len(p) > 0 && p[0] == ansi.CSI:
+		// Normalize to a 7-bit introducer.
+		p = append([]byte{ansi.ESC, '['}, p[1:]...)
+	case len(p) < 2 || p[0] != ansi.ESC || p[1] != '[':
+		return nil, false
+	}
+
+	var e Event
+	switch {
+	case len(p) > 3 && p[2] == '<' && (p[len(p)-1] == 'M' || p[len(p)-1] == 'm'):
+		e = parseSGRMouseEvent(p, false)
+	case len(p) == 6 && p[2] == 'M':
+		e = parseX10MouseEvent(p)
+	default:
+		return nil, false
+	}
+
+	switch e.(type) {
+	case MouseDownEvent, MouseUpEvent, MouseMoveEvent:
+		return e, true
+	}
+
+	return nil, false
+}
 
 // Parse SGR-encoded mouse events; SGR extended mouse events. SGR mouse events
 // look like:
@@ -152,25 +199,24 @@ var mouseSGRRegex = regexp.MustCompile(`(\d+);(\d+);(\d+)([Mm])`)
 //	Cy is the y-coordinate of the mouse
 //	M is for button press, m is for button release
 //
+// SGR-Pixels mouse events (DECSET 1016) use the same encoding but report the
+// coordinates in pixels. When pixels is true, the coordinates are reported as
+// is since pixel coordinates are already zero-based offsets.
+//
 // https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Extended-coordinates
-func parseSGRMouseEvent(buf []byte) Event {
-	str := string(buf[3:])
-	matches := mouseSGRRegex.FindStringSubmatch(str)
-	if len(matches) != 5 {
+func parseSGRMouseEvent(buf []byte, pixels bool) Event {
+	b, x, y, release, ok := scanSGRMouse(buf[3:])
+	if !ok {
 		return UnknownCsiEvent(buf)
 	}
 
-	b, _ := strconv.Atoi(matches[1])
-	px := matches[2]
-	py := matches[3]
-	release := matches[4] == "m"
 	mod, btn, _, isMotion := parseMouseButton(b)
-	x, _ := strconv.Atoi(px)
-	y, _ := strconv.Atoi(py)
 
-	// (1,1) is the upper left. We subtract 1 to normalize it to (0,0).
-	x--
-	y--
+	if !pixels {
+		// (1,1) is the upper left. We subtract 1 to normalize it to (0,0).
+		x--
+		y--
+	}
 
 	// Wheel buttons don't have release events
 	// Motion can be reported as a release event in some terminals (Windows Terminal)
@@ -182,6 +228,60 @@ func parseSGRMouseEvent(buf []byte) Event {
 	return MouseDownEvent{X: x, Y: y, Button: btn, Mod: mod}
 }
 
+// scanSGRMouse finds the first Cb ; Cx ; Cy (M or m) match in p and returns
+// its values. It doesn't allocate, mouse events can be reported at a high
+// rate when motion tracking is enabled.
+func scanSGRMouse(p []byte) (b, x, y int, release, ok bool) {
+	for i := range p {
+		if b, x, y, release, ok = matchSGRMouse(p[i:]); ok {
+			return
+		}
+	}
+	return
+}
+
+// matchSGRMouse matches a Cb ; Cx ; Cy (M or m) prefix of p.
+func matchSGRMouse(p []byte) (b, x, y int, release, ok bool) {
+	var vals [3]int
+	var i int
+	for n := range vals {
+		start := i
+		for i < len(p) && p[i] >= '0' && p[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return
+		}
+		vals[n] = atoi(p[start:i])
+		if n < len(vals)-1 {
+			if i >= len(p) || p[i] != ';' {
+				return
+			}
+			i++
+		}
+	}
+
+	if i >= len(p) || (p[i] != 'M' && p[i] != 'm') {
+		return
+	}
+
+	return vals[0], vals[1], vals[2], p[i] == 'm', true
+}
+
+// atoi converts a string of decimal digits to an int. Values out of range
+// are clamped to math.MaxInt like strconv.Atoi does.
+func atoi(p []byte) int {
+	var n int
+	for _, c := range p {
+		d := int(c - '0')
+		if n > (math.MaxInt-d)/10 {
+			return math.MaxInt
+		}
+		n = n*10 + d
+	}
+	return n
+}
+
 const x10MouseByteOffset = 32
 
 // Parse X10-encoded mouse events; the simplest kind. The last release of X10
@@ -215,6 +315,34 @@ func parseX10MouseEvent(buf []byte) Event {
 	return MouseDownEvent{X: x, Y: y, Button: btn, Mod: mod}
 }
 
+// Parse URxvt-encoded mouse events (DECSET 1015). These use the X10 button
+// encoding, offset by 32, with decimal 1-based coordinates.
+//
+// URxvt mouse events look like:
+//
+//	ESC [ Cb ; Cx ; Cy M
+//
+// See: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Extended-coordinates
+func parseURxvtMouseEvent(params [][]uint) Event {
+	b := int(params[0][0])
+	if b >= x10MouseByteOffset {
+		b -= x10MouseByteOffset
+	}
+
+	mod, btn, isRelease, isMotion := parseMouseButton(b)
+
+	// (1,1) is the upper left. We subtract 1 to normalize it to (0,0).
+	x := int(params[1][0]) - 1
+	y := int(params[2][0]) - 1
+
+	if isMotion {
+		return MouseMoveEvent{X: x, Y: y, Button: btn, Mod: mod}
+	} else if isRelease {
+		return MouseUpEvent{X: x, Y: y, Button: btn, Mod: mod}
+	}
+	return MouseDownEvent{X: x, Y: y, Button: btn, Mod: mod}
+}
+
 // See: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Extended-coordinates
 func parseMouseButton(b int) (mod Mod, btn MouseButton, isRelease bool, isMotion bool) {
 	// mouse bit shifts
@@ -241,15 +369,17 @@ func parseMouseButton(b int) (mod Mod, btn MouseButton, isRelease bool, isMotion
 	}
 
 	if b&bitAdd != 0 {
+		// 128-131 are buttons 8-11
 		btn = MouseButtonBackward + MouseButton(b&bitsMask)
 	} else if b&bitWheel != 0 {
 		btn = MouseButtonWheelUp + MouseButton(b&bitsMask)
 	} else {
 		btn = MouseButtonLeft + MouseButton(b&bitsMask)
-		// X10 reports a button release as 0b0000_0011 (3)
+		// X10 reports a button release as 0b0000_0011 (3). With the motion
+		// bit set, it's a motion event without a button pressed i.e. hover.
 		if b&bitsMask == bitsMask {
 			btn = MouseButtonNone
-			isRelease = true
+			isRelease = b&bitMotion == 0
 		}
 	}
 
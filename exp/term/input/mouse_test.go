@@ -0,0 +1,335 @@
+package input
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestMouseDelta(t *testing.T) {
+	in := "\x1b[<35;10;5M" + // move to (9,4)
+		"\x1b[<35;12;8M" + // move by (2,3)
+		"\x1b[<0;12;8M" + // left press, resets delta
+		"\x1b[<32;15;7M" + // drag by (3,-1)
+		"\x1b[<32;14;7M" + // drag by (-1,0)
+		"\x1b[<0;14;7m" // left release, no movement
+
+	want := []Event{
+		MouseMoveEvent{X: 9, Y: 4},
+		MouseMoveEvent{X: 11, Y: 7, DX: 2, DY: 3},
+		MouseDownEvent{X: 11, Y: 7, Button: MouseButtonLeft},
+		MouseMoveEvent{X: 14, Y: 6, DX: 3, DY: -1, Button: MouseButtonLeft},
+		MouseMoveEvent{X: 13, Y: 6, DX: -1, Button: MouseButtonLeft},
+		MouseUpEvent{X: 13, Y: 6, Button: MouseButtonLeft},
+	}
+
+	if got := readEvents(t, FlagMouseDelta, in); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+
+	// Without the flag, deltas are not reported.
+	for i, e := range want {
+		switch e := e.(type) {
+		case MouseMoveEvent:
+			e.DX, e.DY = 0, 0
+			want[i] = e
+		}
+	}
+	if got := readEvents(t, 0, in); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
+
+func TestParseMouse(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+		ok   bool
+	}{
+		// SGR
+		{"\x1b[<0;10;5M", MouseDownEvent{X: 9, Y: 4, Button: MouseButtonLeft}, true},
+		{"\x1b[<0;10;5m", MouseUpEvent{X: 9, Y: 4, Button: MouseButtonLeft}, true},
+		{"\x1b[<35;1;1M", MouseMoveEvent{}, true},
+		{"\x1b[<64;3;4M", MouseDownEvent{X: 2, Y: 3, Button: MouseButtonWheelUp}, true},
+		{"\x1b[<16;3;4M", MouseDownEvent{X: 2, Y: 3, Button: MouseButtonLeft, Mod: Ctrl}, true},
+		{"\x9b<2;3;4M", MouseDownEvent{X: 2, Y: 3, Button: MouseButtonRight}, true},
+
+		// X10
+		{"\x1b[M !!", MouseDownEvent{Button: MouseButtonLeft}, true},
+		{"\x1b[M#!!", MouseUpEvent{Button: MouseButtonNone}, true},
+		{"\x1b[M`*+", MouseDownEvent{X: 9, Y: 10, Button: MouseButtonWheelUp}, true},
+		{"\x9bM !!", MouseDownEvent{Button: MouseButtonLeft}, true},
+
+		// Not mouse sequences
+		{"\x1b[A", nil, false},
+		{"\x1b[<0;10;5", nil, false},
+		{"\x1b[<a;b;cM", nil, false},
+		{"\x1b[M !", nil, false},
+		{"a", nil, false},
+		{"", nil, false},
+	}
+
+	for _, c := range cases {
+		e, ok := ParseMouse([]byte(c.seq))
+		if ok != c.ok {
+			t.Errorf("%q: expected ok=%v, got %v", c.seq, c.ok, ok)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+}
+
+func TestMousePixels(t *testing.T) {
+	in := "\x1b[<0;120;48M\x1b[<35;0;0M\x1b[<0;121;49m"
+
+	cells := []Event{
+		MouseDownEvent{X: 119, Y: 47, Button: MouseButtonLeft},
+		MouseMoveEvent{X: -1, Y: -1},
+		MouseUpEvent{X: 120, Y: 48, Button: MouseButtonLeft},
+	}
+	if got := DecodeString(in); !reflect.DeepEqual(got, cells) {
+		t.Errorf("expected %v, got %v", cells, got)
+	}
+
+	pixels := []Event{
+		MouseDownEvent{X: 120, Y: 48, Button: MouseButtonLeft},
+		MouseMoveEvent{X: 0, Y: 0},
+		MouseUpEvent{X: 121, Y: 49, Button: MouseButtonLeft},
+	}
+	if got := DecodeStringWith(in, FlagMousePixels); !reflect.DeepEqual(got, pixels) {
+		t.Errorf("expected %v, got %v", pixels, got)
+	}
+}
+
+var mouseSGRRegex = regexp.MustCompile(`(\d+);(\d+);(\d+)([Mm])`)
+
+// parseSGRMouseEventRegexp is the regexp based SGR mouse parser that
+// parseSGRMouseEvent replaced. It's used as a reference implementation.
+func parseSGRMouseEventRegexp(buf []byte) Event {
+	str := string(buf[3:])
+	matches := mouseSGRRegex.FindStringSubmatch(str)
+	if len(matches) != 5 {
+		return UnknownCsiEvent(buf)
+	}
+
+	b, _ := strconv.Atoi(matches[1])
+	px := matches[2]
+	py := matches[3]
+	release := matches[4] == "m"
+	mod, btn, _, isMotion := parseMouseButton(b)
+	x, _ := strconv.Atoi(px)
+	y, _ := strconv.Atoi(py)
+
+	x--
+	y--
+
+	if !isMotion && !isWheel(btn) && release {
+		return MouseUpEvent{X: x, Y: y, Button: btn, Mod: mod}
+	} else if isMotion {
+		return MouseMoveEvent{X: x, Y: y, Button: btn, Mod: mod}
+	}
+	return MouseDownEvent{X: x, Y: y, Button: btn, Mod: mod}
+}
+
+func TestParseSGRMouseEventMatchesRegexp(t *testing.T) {
+	cases := []string{
+		"\x1b[<0;1;1M",
+		"\x1b[<35;120;48m",
+		"\x1b[<64;10;10M",
+		"\x1b[<0;1;2;3M",
+		"\x1b[<0;1M",
+		"\x1b[<;1;1M",
+		"\x1b[<0;1;1",
+		"\x1b[<0;99999999999999999999;1M",
+		"\x1b[<0;1;1Mx",
+	}
+
+	const alphabet = "0123456789;;;:<Mmx"
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		seq := []byte("\x1b[<")
+		for n := rnd.Intn(24); n > 0; n-- {
+			seq = append(seq, alphabet[rnd.Intn(len(alphabet))])
+		}
+		cases = append(cases, string(seq))
+	}
+
+	for _, c := range cases {
+		want := parseSGRMouseEventRegexp([]byte(c))
+		if got := parseSGRMouseEvent([]byte(c), false); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %#v, got %#v", c, want, got)
+		}
+	}
+}
+
+func BenchmarkParseSGRMouseEvent(b *testing.B) {
+	seq := []byte("\x1b[<35;120;48M")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		parseSGRMouseEvent(seq, false)
+	}
+}
+
+func BenchmarkParseSGRMouseEventRegexp(b *testing.B) {
+	seq := []byte("\x1b[<35;120;48M")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		parseSGRMouseEventRegexp(seq)
+	}
+}
+
+func TestParseURxvtMouseEvent(t *testing.T) {
+	cases := []struct {
+		name string
+		seq  string
+		want Event
+	}{
+		{"left press", "\x1b[32;10;5M", MouseDownEvent{X: 9, Y: 4, Button: MouseButtonLeft}},
+		{"right press", "\x1b[34;1;1M", MouseDownEvent{Button: MouseButtonRight}},
+		{"release", "\x1b[35;10;5M", MouseUpEvent{X: 9, Y: 4}},
+		{"wheel up", "\x1b[96;3;4M", MouseDownEvent{X: 2, Y: 3, Button: MouseButtonWheelUp}},
+		{"wheel down", "\x1b[97;3;4M", MouseDownEvent{X: 2, Y: 3, Button: MouseButtonWheelDown}},
+		{"left drag", "\x1b[64;300;200M", MouseMoveEvent{X: 299, Y: 199, Button: MouseButtonLeft}},
+		{"motion", "\x1b[67;2;2M", MouseMoveEvent{X: 1, Y: 1}},
+		{"shift+left", "\x1b[36;1;1M", MouseDownEvent{Button: MouseButtonLeft, Mod: Shift}},
+		{"ctrl+alt+middle", "\x1b[57;1;1M", MouseDownEvent{Button: MouseButtonMiddle, Mod: Ctrl | Alt}},
+		{"ctrl+wheel up", "\x1b[112;1;1M", MouseDownEvent{Button: MouseButtonWheelUp, Mod: Ctrl}},
+		{"missing params", "\x1b[32;10M", UnknownCsiEvent("\x1b[32;10M")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			n, e := ParseSequence([]byte(c.seq))
+			if n != len(c.seq) {
+				t.Errorf("expected length %d, got %d", len(c.seq), n)
+			}
+			if !reflect.DeepEqual(e, c.want) {
+				t.Errorf("expected %#v, got %#v", c.want, e)
+			}
+		})
+	}
+
+	// X10 mouse events are still supported
+	want := []Event{MouseDownEvent{X: 9, Y: 4, Button: MouseButtonLeft}}
+	if got := DecodeString("\x1b[M *%"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParseMouseAdditionalButtons(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		// SGR
+		{"\x1b[<128;1;1M", MouseDownEvent{Button: MouseButtonBackward}},
+		{"\x1b[<129;1;1M", MouseDownEvent{Button: MouseButtonForward}},
+		{"\x1b[<130;1;1M", MouseDownEvent{Button: MouseButton10}},
+		{"\x1b[<131;1;1M", MouseDownEvent{Button: MouseButton11}},
+		{"\x1b[<128;1;1m", MouseUpEvent{Button: MouseButtonBackward}},
+		{"\x1b[<131;1;1m", MouseUpEvent{Button: MouseButton11}},
+		{"\x1b[<161;2;3M", MouseMoveEvent{X: 1, Y: 2, Button: MouseButtonForward}},
+		{"\x1b[<148;1;1M", MouseDownEvent{Button: MouseButtonBackward, Mod: Ctrl | Shift}},
+		{"\x1b[<138;1;1M", MouseDownEvent{Button: MouseButton10, Mod: Alt}},
+
+		// X10, the button byte is offset by 32
+		{"\x1b[M\xa0!!", MouseDownEvent{Button: MouseButtonBackward}},
+		{"\x1b[M\xa1!!", MouseDownEvent{Button: MouseButtonForward}},
+		{"\x1b[M\xa2!!", MouseDownEvent{Button: MouseButton10}},
+		{"\x1b[M\xa3!!", MouseDownEvent{Button: MouseButton11}},
+	}
+
+	for _, c := range cases {
+		e, ok := ParseMouse([]byte(c.seq))
+		if !ok {
+			t.Errorf("%q: expected a mouse event", c.seq)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+}
+
+func TestMouseButtonString(t *testing.T) {
+	cases := []struct {
+		b    MouseButton
+		want string
+	}{
+		{MouseButtonNone, "none"},
+		{MouseButtonLeft, "left"},
+		{MouseButtonWheelRight, "wheel right"},
+		{MouseButtonBackward, "backward"},
+		{MouseButtonForward, "forward"},
+		{MouseButton10, "button 10"},
+		{MouseButton11, "button 11"},
+		{MouseButton11 + 1, "unknown"},
+	}
+
+	for _, c := range cases {
+		if got := c.b.String(); got != c.want {
+			t.Errorf("%d: expected %q, got %q", c.b, c.want, got)
+		}
+	}
+
+	if got, want := (MouseDownEvent{Button: MouseButton10, Mod: Ctrl}).String(), "ctrl+button 10"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestMouseString(t *testing.T) {
+	cases := []struct {
+		e    Event
+		want string
+	}{
+		{MouseDownEvent{Button: MouseButtonLeft}, "left"},
+		{MouseDownEvent{Button: MouseButtonLeft, Mod: Shift | Ctrl}, "ctrl+shift+left"},
+		{MouseUpEvent{Button: MouseButtonRight, Mod: Meta}, "meta+right"},
+		{MouseDownEvent{Button: MouseButtonWheelUp, Mod: Super | Alt}, "alt+super+wheel up"},
+		{MouseMoveEvent{Mod: Shift}, "shift+"},
+	}
+	for _, c := range cases {
+		if got := c.e.(fmt.Stringer).String(); got != c.want {
+			t.Errorf("%#v: expected %q, got %q", c.e, c.want, got)
+		}
+	}
+}
+
+func TestMouseHover(t *testing.T) {
+	cases := []struct {
+		name string
+		seq  string
+		want Event
+	}{
+		{"sgr drag", "\x1b[<32;5;6M", MouseMoveEvent{X: 4, Y: 5, Button: MouseButtonLeft}},
+		{"sgr hover", "\x1b[<35;5;6M", MouseMoveEvent{X: 4, Y: 5}},
+		{"sgr shift hover", "\x1b[<39;5;6M", MouseMoveEvent{X: 4, Y: 5, Mod: Shift}},
+		{"sgr release", "\x1b[<0;5;6m", MouseUpEvent{X: 4, Y: 5, Button: MouseButtonLeft}},
+		{"x10 drag", "\x1b[M@%&", MouseMoveEvent{X: 4, Y: 5, Button: MouseButtonLeft}},
+		{"x10 hover", "\x1b[MC%&", MouseMoveEvent{X: 4, Y: 5}},
+		{"x10 release", "\x1b[M#%&", MouseUpEvent{X: 4, Y: 5}},
+		{"urxvt drag", "\x1b[64;5;6M", MouseMoveEvent{X: 4, Y: 5, Button: MouseButtonLeft}},
+		{"urxvt hover", "\x1b[67;5;6M", MouseMoveEvent{X: 4, Y: 5}},
+		{"urxvt release", "\x1b[35;5;6M", MouseUpEvent{X: 4, Y: 5}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DecodeString(c.seq); !reflect.DeepEqual(got, []Event{c.want}) {
+				t.Errorf("expected %#v, got %#v", c.want, got)
+			}
+		})
+	}
+
+	for _, b := range []int{35, 35 + 4, 35 + 8, 35 + 16} {
+		if _, btn, isRelease, isMotion := parseMouseButton(b); btn != MouseButtonNone || isRelease || !isMotion {
+			t.Errorf("%d: expected a hover motion, got button %v, release %v, motion %v", b, btn, isRelease, isMotion)
+		}
+	}
+	if _, _, isRelease, isMotion := parseMouseButton(3); !isRelease || isMotion {
+		t.Errorf("3: expected a release, got release %v, motion %v", isRelease, isMotion)
+	}
+}
@@ -0,0 +1,122 @@
+package input
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option configures a Driver created by NewDriver or a Parser created by
+// NewParser.
+type Option func(*options)
+
+// options holds the Driver configuration.
+type options struct {
+	term          string
+	flags         int
+	profile       Profile
+	escTimeout    time.Duration
+	clickInterval time.Duration
+
+	modifyOtherKeys ModifyOtherKeysEvent
+	err             error
+}
+
+// validate returns the error of an invalid option, or ErrInvalidConfig if
+// the options contradict each other.
+func (o *options) validate() error {
+	if o.err != nil {
+		return o.err
+	}
+	if o.flags&FlagKeyPressOnly != 0 && o.flags&FlagKeyReleaseOnly != 0 {
+		return fmt.Errorf("%w: FlagKeyPressOnly and FlagKeyReleaseOnly are both set", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// apply applies the parser options that need the key table to be built
+// first.
+func (o *options) apply(p *Parser) {
+	if o.profile != "" {
+		p.profile = o.profile
+		p.registerKeys(p.flags)
+	}
+	p.SetClickInterval(o.clickInterval)
+	p.SetModifyOtherKeys(o.modifyOtherKeys)
+}
+
+// WithFlags sets flags to control the behavior of the driver e.g.
+// FlagCtrlM | FlagMouseDelta. Flags from multiple WithFlags options are
+// combined.
+func WithFlags(flags int) Option {
+	return func(o *options) {
+		o.flags |= flags
+	}
+}
+
+// WithConfig sets the key table options of the driver. Options from the
+// config are combined with the ones set by WithFlags. NewDriver returns an
+// error if the config is invalid, see Config.Validate.
+func WithConfig(c Config) Option {
+	return func(o *options) {
+		if err := c.Validate(); err != nil {
+			o.err = err
+			return
+		}
+		o.flags |= c.flags()
+	}
+}
+
+// WithTerminfo sets the terminal name, usually $TERM, used to read the
+// Terminfo key sequences, unless FlagNoTerminfo is set.
+func WithTerminfo(term string) Option {
+	return func(o *options) {
+		o.term = term
+	}
+}
+
+// WithProfile sets the terminal key profile of the driver instead of
+// detecting it from the terminal name set by WithTerminfo.
+func WithProfile(p Profile) Option {
+	return func(o *options) {
+		o.profile = p
+	}
+}
+
+// WithEscTimeout sets how long ReadEvents waits for more input after a
+// trailing ESC. See Driver.SetEscTimeout.
+func WithEscTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.escTimeout = timeout
+	}
+}
+
+// WithClickInterval sets the maximum time between consecutive mouse presses
+// to count them as a multi-click. See Driver.SetClickInterval.
+func WithClickInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.clickInterval = interval
+	}
+}
+
+// WithModifyOtherKeys sets the XTerm modifyOtherKeys mode the terminal is
+// in. See Driver.SetModifyOtherKeys.
+func WithModifyOtherKeys(mode ModifyOtherKeysEvent) Option {
+	return func(o *options) {
+		o.modifyOtherKeys = mode
+	}
+}
+
+// WithExpandRepeats sets whether key events with a repeat count, e.g. a key
+// held down on Windows Console, are reported as one event per repeat, the
+// default, or as a single event with IsRepeat and RepeatCount set. It clears
+// or sets FlagCollapseRepeats, so the last of WithExpandRepeats and a
+// WithFlags option setting FlagCollapseRepeats wins.
+func WithExpandRepeats(expand bool) Option {
+	return func(o *options) {
+		if expand {
+			o.flags &^= FlagCollapseRepeats
+		} else {
+			o.flags |= FlagCollapseRepeats
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package input
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewDriverOptions(t *testing.T) {
+	d, err := NewDriver(strings.NewReader(""),
+		WithFlags(FlagCtrlI),
+		WithFlags(FlagSpace),
+		WithEscTimeout(time.Second),
+		WithClickInterval(time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := FlagCtrlI | FlagSpace; d.parser.flags != want {
+		t.Errorf("expected flags %b, got %b", want, d.parser.flags)
+	}
+	if d.escTimeout != time.Second {
+		t.Errorf("expected esc timeout %v, got %v", time.Second, d.escTimeout)
+	}
+	if d.parser.clickInterval != time.Millisecond {
+		t.Errorf("expected click interval %v, got %v", time.Millisecond, d.parser.clickInterval)
+	}
+	if k := d.parser.table["\t"]; k != (KeyDownEvent{Rune: 'i', Mod: Ctrl}) {
+		t.Errorf("expected flags to apply to the key table, got %v", k)
+	}
+}
+
+func TestNewDriverDefaults(t *testing.T) {
+	d, err := NewDriver(strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.parser.flags != 0 {
+		t.Errorf("expected no flags, got %b", d.parser.flags)
+	}
+	if d.escTimeout != DefaultEscTimeout {
+		t.Errorf("expected esc timeout %v, got %v", DefaultEscTimeout, d.escTimeout)
+	}
+	if d.parser.clickInterval != DefaultClickInterval {
+		t.Errorf("expected click interval %v, got %v", DefaultClickInterval, d.parser.clickInterval)
+	}
+}
+
+func TestWithExpandRepeats(t *testing.T) {
+	// Win32 input mode Enter key held down with a repeat count of 3
+	in := []byte("\x1b[13;28;13;1;0;3_")
+	expanded := KeyDownEvent{Sym: KeyEnter, IsRepeat: true}
+	collapsed := KeyDownEvent{Sym: KeyEnter, IsRepeat: true, RepeatCount: 3}
+
+	cases := []struct {
+		name string
+		opts []Option
+		want []Event
+	}{
+		{"default", nil, []Event{expanded, expanded, expanded}},
+		{"expand", []Option{WithExpandRepeats(true)}, []Event{expanded, expanded, expanded}},
+		{"collapse", []Option{WithExpandRepeats(false)}, []Event{collapsed}},
+		{"overrides flag", []Option{WithFlags(FlagCollapseRepeats), WithExpandRepeats(true)}, []Event{expanded, expanded, expanded}},
+		{"overridden by flag", []Option{WithExpandRepeats(true), WithFlags(FlagCollapseRepeats)}, []Event{collapsed}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d, err := NewDriver(strings.NewReader(""), c.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := d.parser.decode(in); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
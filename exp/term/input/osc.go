@@ -0,0 +1,84 @@
+package input
+
+import (
+	"strconv"
+	"strings"
+)
+
+// oscHandlers maps OSC command numbers to the parsers of their payload. A
+// parser returns nil if the payload is malformed.
+var oscHandlers = map[int]func(code int, payload string) Event{
+	4:  parseOscPalette,
+	10: parseOscColors,
+	11: parseOscColors,
+	12: parseOscColors,
+	52: parseOscClipboard,
+}
+
+// parseOscData parses the data of an OSC sequence i.e. the command and its
+// payload without the introducer and the terminator, and dispatches it to
+// the command parser. It returns nil if the command is unknown or the
+// payload is malformed.
+func parseOscData(data []byte) Event {
+	if len(data) == 0 {
+		return nil
+	}
+
+	// Window title reports don't have a separator between the command and
+	// the label i.e. OSC l <label> ST. The label might be empty.
+	switch data[0] {
+	case 'l':
+		return WindowLabelEvent{Label: string(data[1:])}
+	case 'L':
+		return IconLabelEvent{Label: string(data[1:])}
+	}
+
+	code, payload, ok := splitOsc(string(data))
+	if !ok || len(payload) == 0 {
+		return nil
+	}
+
+	parse, ok := oscHandlers[code]
+	if !ok {
+		return nil
+	}
+	return parse(code, payload)
+}
+
+// splitOsc splits the data of an OSC sequence into the command number and
+// the payload i.e. Ps ; Pt. It reports false if the command isn't a number.
+func splitOsc(data string) (code int, payload string, ok bool) {
+	cmd, payload, ok := strings.Cut(data, ";")
+	if !ok || len(cmd) == 0 {
+		return 0, "", false
+	}
+	for i := 0; i < len(cmd); i++ {
+		if cmd[i] < '0' || cmd[i] > '9' {
+			return 0, "", false
+		}
+	}
+
+	code, err := strconv.Atoi(cmd)
+	if err != nil {
+		return 0, "", false
+	}
+	return code, payload, true
+}
+
+// oscData returns the data of an OSC sequence without the introducer and the
+// terminator.
+func oscData(seq string) string {
+	switch {
+	case strings.HasPrefix(seq, "\x1b]"):
+		seq = seq[2:]
+	case strings.HasPrefix(seq, "\x9d"):
+		seq = seq[1:]
+	}
+	switch {
+	case strings.HasSuffix(seq, "\x1b\\"):
+		seq = seq[:len(seq)-2]
+	case strings.HasSuffix(seq, "\x07"), strings.HasSuffix(seq, "\x9c"):
+		seq = seq[:len(seq)-1]
+	}
+	return seq
+}
@@ -0,0 +1,64 @@
+package input
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestParseOscDispatch(t *testing.T) {
+	cases := []struct {
+		name string
+		seq  string
+		want Event
+	}{
+		{"bel", "\x1b]11;rgb:0000/0000/0000\x07", BackgroundColorEvent{color.RGBA{0, 0, 0, 255}}},
+		{"st", "\x1b]11;rgb:0000/0000/0000\x1b\\", BackgroundColorEvent{color.RGBA{0, 0, 0, 255}}},
+		{"8-bit", "\x9d11;rgb:0000/0000/0000\x9c", BackgroundColorEvent{color.RGBA{0, 0, 0, 255}}},
+		{"clipboard", "\x1b]52;c;aGk=\x07", ClipboardEvent{Selection: 'c', Content: "hi"}},
+		{"window label", "\x1b]lhello\x1b\\", WindowLabelEvent{Label: "hello"}},
+		{"icon label", "\x1b]L\x1b\\", IconLabelEvent{}},
+		{"unknown code", "\x1b]999;data\x07", UnknownOscEvent("\x1b]999;data\x07")},
+		{"malformed payload", "\x1b]10;nope\x07", UnknownOscEvent("\x1b]10;nope\x07")},
+		{"empty payload", "\x1b]10;\x07", UnknownOscEvent("\x1b]10;\x07")},
+		{"no payload", "\x1b]10\x07", UnknownOscEvent("\x1b]10\x07")},
+		{"non numeric code", "\x1b]1a;x\x07", UnknownOscEvent("\x1b]1a;x\x07")},
+		{"empty", "\x1b]\x07", UnknownOscEvent("\x1b]\x07")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			n, e := ParseSequence([]byte(c.seq))
+			if n != len(c.seq) {
+				t.Errorf("expected %d bytes, got %d", len(c.seq), n)
+			}
+			if !reflect.DeepEqual(e, c.want) {
+				t.Errorf("expected %#v, got %#v", c.want, e)
+			}
+		})
+	}
+}
+
+func TestUnknownOscEventCodeData(t *testing.T) {
+	cases := []struct {
+		e    UnknownOscEvent
+		code int
+		data []byte
+	}{
+		{"\x1b]999;some;data\x07", 999, []byte("some;data")},
+		{"\x1b]4;1;rgb:ff/00/00\x1b\\", 4, []byte("1;rgb:ff/00/00")},
+		{"\x9d7;file:///tmp\x9c", 7, []byte("file:///tmp")},
+		{"\x1b]10;\x07", 10, []byte{}},
+		{"\x1b]10\x07", -1, nil},
+		{"\x1b]x;y\x07", -1, nil},
+	}
+
+	for _, c := range cases {
+		if got := c.e.Code(); got != c.code {
+			t.Errorf("%q: expected code %d, got %d", c.e, c.code, got)
+		}
+		if got := c.e.Data(); !reflect.DeepEqual(got, c.data) {
+			t.Errorf("%q: expected data %q, got %q", c.e, c.data, got)
+		}
+	}
+}
@@ -11,12 +11,26 @@ import (
 // with its length.
 //
 // It will return zero and nil no sequence is recognized or when the buffer is
-// empty. If a sequence is not supported, an UnknownEvent is returned.
+// empty. If a sequence is not supported, an UnknownEvent is returned. A
+// sequence cancelled by a CAN (0x18) or SUB (0x1a) character returns its
+// length, including the cancelling character, and an IgnoredEvent. So does a
+// win32-input-mode key that isn't reported e.g. a Caps Lock press.
 func ParseSequence(buf []byte) (n int, e Event) {
+	return parseSequence(buf, 0)
+}
+
+// parseSequence is like ParseSequence but honors the driver flags that change
+// how sequences are interpreted e.g. FlagMousePixels.
+func parseSequence(buf []byte, flags int) (n int, e Event) {
 	if len(buf) == 0 {
 		return 0, nil
 	}
 
+	if flags&FlagNoC1 != 0 && buf[0] >= 0x80 && buf[0] <= 0x9f {
+		// Treat it as a stray UTF-8 continuation byte.
+		return parseUtf8(buf)
+	}
+
 	switch b := buf[0]; b {
 	case ansi.ESC:
 		if len(buf) == 1 {
@@ -30,7 +44,7 @@ func ParseSequence(buf []byte) (n int, e Event) {
 		case 'P': // Esc-prefixed DCS
 			return parseDcs(buf)
 		case '[': // Esc-prefixed CSI
-			return parseCsi(buf)
+			return parseCsi(buf, flags)
 		case ']': // Esc-prefixed OSC
 			return parseOsc(buf)
 		case '_': // Esc-prefixed APC
@@ -42,7 +56,7 @@ func ParseSequence(buf []byte) (n int, e Event) {
 			}
 			fallthrough
 		default:
-			n, e := ParseSequence(buf[1:])
+			n, e := parseSequence(buf[1:], flags)
 			if k, ok := e.(KeyDownEvent); ok {
 				k.Mod |= Alt
 				return n + 1, k
@@ -55,7 +69,7 @@ func ParseSequence(buf []byte) (n int, e Event) {
 	case ansi.DCS:
 		return parseDcs(buf)
 	case ansi.CSI:
-		return parseCsi(buf)
+		return parseCsi(buf, flags)
 	case ansi.OSC:
 		return parseOsc(buf)
 	case ansi.APC:
@@ -68,7 +82,7 @@ func ParseSequence(buf []byte) (n int, e Event) {
 	}
 }
 
-func parseCsi(p []byte) (int, Event) {
+func parseCsi(p []byte, flags int) (int, Event) {
 	var seq []byte
 	var i int
 	if p[i] == ansi.CSI || p[i] == ansi.ESC {
@@ -95,9 +109,22 @@ func parseCsi(p []byte) (int, Event) {
 
 	end = i
 
+	// Intermediate CSI byte
+	var intermed byte
+
 	// Scan intermediate bytes in the range 0x20-0x2F
 	for ; i < len(p) && p[i] >= 0x20 && p[i] <= 0x2F; i++ {
+		intermed = p[i]
 		seq = append(seq, p[i])
+		if intermed == '$' && (i+1 >= len(p) || p[i+1] != 'y') {
+			// URxvt shifted keys use '$' as the final byte i.e. CSI 7 $
+			// The only '$' sequence we care about otherwise is DECRPM.
+			return len(seq), UnknownCsiEvent(seq)
+		}
+	}
+
+	if i < len(p) && isCancel(p[i]) {
+		return i + 1, IgnoredEvent{}
 	}
 
 	// Final byte
@@ -115,16 +142,37 @@ func parseCsi(p []byte) (int, Event) {
 	switch initial {
 	case '?':
 		switch final {
+		case 'y':
+			if intermed != '$' {
+				return len(seq), UnknownCsiEvent(seq)
+			}
+			// Report DEC private mode (DECRPM)
+			params := ansi.Params(p[start:end])
+			if len(params) != 2 {
+				return len(seq), UnknownCsiEvent(seq)
+			}
+			return len(seq), parseModeReport(params, true)
+		case 'R':
+			// Extended cursor position report (DECXCPR) i.e.
+			// CSI ? Pl ; Pc [; Pp] R
+			params := ansi.Params(p[start:end])
+			if len(params) < 2 || len(params) > 3 {
+				return len(seq), UnknownCsiEvent(seq)
+			}
+			return len(seq), parseCursorPosition(params)
 		case 'c':
 			// Primary Device Attributes
-			params := ansi.Params(p[start:end])
-			return len(seq), parsePrimaryDevAttrs(params)
+			if e := parsePrimaryDevAttrs(p[start+1 : end]); e != nil {
+				return len(seq), e
+			}
+			return len(seq), UnknownCsiEvent(seq)
 		case 'u':
-			// Kitty keyboard flags
-			params := ansi.Params(p[start:end])
-			if len(params) == 0 {
+			// Kitty keyboard flags i.e. CSI ? flags u
+			// The query itself has no flags i.e. CSI ? u
+			if intermed != 0 || !isDigits(p[start+1:end]) {
 				return len(seq), UnknownCsiEvent(seq)
 			}
+			params := ansi.Params(p[start:end])
 			return len(seq), KittyKeyboardEvent(params[0][0])
 		default:
 			return len(seq), UnknownCsiEvent(seq)
@@ -133,12 +181,16 @@ func parseCsi(p []byte) (int, Event) {
 		switch final {
 		case 'm', 'M':
 			// Handle SGR mouse
-			return len(seq), parseSGRMouseEvent(seq)
+			return len(seq), parseSGRMouseEvent(seq, flags&FlagMousePixels != 0)
 		default:
 			return len(seq), UnknownCsiEvent(seq)
 		}
 	case '>':
 		switch final {
+		case 'c':
+			// Secondary Device Attributes
+			params := ansi.Params(p[start:end])
+			return len(seq), parseSecondaryDevAttrs(params)
 		case 'm':
 			// XTerm modifyOtherKeys
 			params := ansi.Params(p[start:end])
@@ -156,44 +208,106 @@ func parseCsi(p []byte) (int, Event) {
 	}
 
 	switch final {
-	case 'a':
-		return len(seq), KeyDownEvent{Sym: KeyUp, Mod: Shift}
-	case 'b':
-		return len(seq), KeyDownEvent{Sym: KeyDown, Mod: Shift}
-	case 'c':
-		return len(seq), KeyDownEvent{Sym: KeyRight, Mod: Shift}
-	case 'd':
-		return len(seq), KeyDownEvent{Sym: KeyLeft, Mod: Shift}
-	case 'A':
-		return len(seq), KeyDownEvent{Sym: KeyUp}
-	case 'B':
-		return len(seq), KeyDownEvent{Sym: KeyDown}
-	case 'C':
-		return len(seq), KeyDownEvent{Sym: KeyRight}
-	case 'D':
-		return len(seq), KeyDownEvent{Sym: KeyLeft}
-	case 'E':
-		return len(seq), KeyDownEvent{Sym: KeyBegin}
-	case 'F':
-		return len(seq), KeyDownEvent{Sym: KeyEnd}
-	case 'H':
-		return len(seq), KeyDownEvent{Sym: KeyHome}
-	case 'P':
-		return len(seq), KeyDownEvent{Sym: KeyF1}
-	case 'Q':
-		return len(seq), KeyDownEvent{Sym: KeyF2}
-	case 'R':
-		return len(seq), KeyDownEvent{Sym: KeyF3}
-	case 'S':
-		return len(seq), KeyDownEvent{Sym: KeyF4}
-	case 'Z':
-		return len(seq), KeyDownEvent{Sym: KeyTab, Mod: Shift}
+	case 'y':
+		if intermed != '$' {
+			return len(seq), UnknownCsiEvent(seq)
+		}
+		// Report ANSI mode (DECRPM)
+		params := ansi.Params(p[start:end])
+		if len(params) != 2 {
+			return len(seq), UnknownCsiEvent(seq)
+		}
+		return len(seq), parseModeReport(params, false)
+	case 'I', 'O':
+		// Focus reporting CSI I and CSI O
+		if initial != 0 || intermed != 0 {
+			return len(seq), UnknownCsiEvent(seq)
+		}
+		if final == 'I' {
+			return len(seq), FocusEvent{}
+		}
+		return len(seq), BlurEvent{}
+	case 'a', 'b', 'c', 'd', 'A', 'B', 'C', 'D', 'E', 'F', 'H', 'P', 'Q', 'R', 'S', 'Z':
+		if final == 'R' && initial != 0 && intermed == 0 {
+			// A cursor position report (CPR) shares the final byte with
+			// the F3 key i.e. CSI Pl ; Pc R. See isCursorPosition.
+			params := ansi.Params(p[start:end])
+			if isCursorPosition(params) {
+				return len(seq), parseCursorPosition(params)
+			}
+		}
+
+		var k KeyDownEvent
+		switch final {
+		case 'a':
+			k = KeyDownEvent{Sym: KeyUp, Mod: Shift}
+		case 'b':
+			k = KeyDownEvent{Sym: KeyDown, Mod: Shift}
+		case 'c':
+			k = KeyDownEvent{Sym: KeyRight, Mod: Shift}
+		case 'd':
+			k = KeyDownEvent{Sym: KeyLeft, Mod: Shift}
+		case 'A':
+			k = KeyDownEvent{Sym: KeyUp}
+		case 'B':
+			k = KeyDownEvent{Sym: KeyDown}
+		case 'C':
+			k = KeyDownEvent{Sym: KeyRight}
+		case 'D':
+			k = KeyDownEvent{Sym: KeyLeft}
+		case 'E':
+			k = KeyDownEvent{Sym: KeyBegin}
+		case 'F':
+			k = KeyDownEvent{Sym: KeyEnd}
+		case 'H':
+			k = KeyDownEvent{Sym: KeyHome}
+		case 'P':
+			k = KeyDownEvent{Sym: KeyF1}
+		case 'Q':
+			k = KeyDownEvent{Sym: KeyF2}
+		case 'R':
+			k = KeyDownEvent{Sym: KeyF3}
+		case 'S':
+			k = KeyDownEvent{Sym: KeyF4}
+		case 'Z':
+			k = KeyDownEvent{Sym: KeyTab, Mod: Shift}
+		}
+
+		// CSI 1 ; <modifiers> [: <kind>] <func>
+		params := ansi.Params(p[start:end])
+		if len(params) > 1 {
+			k.Mod |= parseXTermModifier(params[1][0])
+			if len(params[1]) > 1 {
+				return len(seq), keyEventKind(params[1][1]).event(k)
+			}
+		} else if len(params) == 1 && final >= 'P' && final <= 'S' {
+			// CSI <modifiers> <func>
+			k.Mod |= parseXTermModifier(params[0][0])
+		}
+		return len(seq), k
 	case 'M':
+		if initial != 0 {
+			// Handle URxvt mouse CSI Cb ; Cx ; Cy M
+			params := ansi.Params(p[start:end])
+			if len(params) != 3 || initial < '0' || initial > '9' {
+				return len(seq), UnknownCsiEvent(seq)
+			}
+			return len(seq), parseURxvtMouseEvent(params)
+		}
 		// Handle X10 mouse
 		if i+3 > len(p) {
 			return len(seq), UnknownCsiEvent(seq)
 		}
 		return len(seq) + 3, parseX10MouseEvent(append(seq, p[i:i+3]...))
+	case 't':
+		// XTWINOPS window reports
+		if initial < '0' || initial > '9' || intermed != 0 {
+			return len(seq), UnknownCsiEvent(seq)
+		}
+		if e := parseWindowReport(ansi.Params(p[start:end])); e != nil {
+			return len(seq), e
+		}
+		return len(seq), UnknownCsiEvent(seq)
 	case 'u':
 		// Kitty keyboard protocol
 		params := ansi.Params(p[start:end])
@@ -213,17 +327,19 @@ func parseCsi(p []byte) (int, Event) {
 			rc = 1
 		}
 
-		event := parseWin32InputKeyEvent(
+		event, ok := parseWin32InputKeyEvent(
 			coninput.VirtualKeyCode(params[0][0]),  // Vk wVirtualKeyCode
 			coninput.VirtualKeyCode(params[1][0]),  // Sc wVirtualScanCode
 			rune(params[2][0]),                     // Uc UnicodeChar
 			params[3][0] == 1,                      // Kd bKeyDown
 			coninput.ControlKeyState(params[4][0]), // Cs dwControlKeyState
 			rc,                                     // Rc wRepeatCount
+			flags&FlagKeypadNav != 0,
 		)
 
-		if event == nil {
-			return len(seq), UnknownCsiEvent(seq)
+		if !ok {
+			// Ignored key records, e.g. lock keys, aren't reported.
+			return len(seq), IgnoredEvent{}
 		}
 
 		return len(seq), event
@@ -232,69 +348,70 @@ func parseCsi(p []byte) (int, Event) {
 		if len(params) == 0 {
 			return len(seq), UnknownCsiEvent(seq)
 		}
+		var k KeyDownEvent
 		switch params[0][0] {
 		case 1:
-			return len(seq), KeyDownEvent{Sym: KeyHome}
+			k = KeyDownEvent{Sym: KeyHome}
 		case 2:
-			return len(seq), KeyDownEvent{Sym: KeyInsert}
+			k = KeyDownEvent{Sym: KeyInsert}
 		case 3:
-			return len(seq), KeyDownEvent{Sym: KeyDelete}
+			k = KeyDownEvent{Sym: KeyDelete}
 		case 4:
-			return len(seq), KeyDownEvent{Sym: KeyEnd}
+			k = KeyDownEvent{Sym: KeyEnd}
 		case 5:
-			return len(seq), KeyDownEvent{Sym: KeyPgUp}
+			k = KeyDownEvent{Sym: KeyPgUp}
 		case 6:
-			return len(seq), KeyDownEvent{Sym: KeyPgDown}
+			k = KeyDownEvent{Sym: KeyPgDown}
 		case 7:
-			return len(seq), KeyDownEvent{Sym: KeyHome}
+			k = KeyDownEvent{Sym: KeyHome}
 		case 8:
-			return len(seq), KeyDownEvent{Sym: KeyEnd}
+			k = KeyDownEvent{Sym: KeyEnd}
 		case 11:
-			return len(seq), KeyDownEvent{Sym: KeyF1}
+			k = KeyDownEvent{Sym: KeyF1}
 		case 12:
-			return len(seq), KeyDownEvent{Sym: KeyF2}
+			k = KeyDownEvent{Sym: KeyF2}
 		case 13:
-			return len(seq), KeyDownEvent{Sym: KeyF3}
+			k = KeyDownEvent{Sym: KeyF3}
 		case 14:
-			return len(seq), KeyDownEvent{Sym: KeyF4}
+			k = KeyDownEvent{Sym: KeyF4}
 		case 15:
-			return len(seq), KeyDownEvent{Sym: KeyF5}
+			k = KeyDownEvent{Sym: KeyF5}
 		case 17:
-			return len(seq), KeyDownEvent{Sym: KeyF6}
+			k = KeyDownEvent{Sym: KeyF6}
 		case 18:
-			return len(seq), KeyDownEvent{Sym: KeyF7}
+			k = KeyDownEvent{Sym: KeyF7}
 		case 19:
-			return len(seq), KeyDownEvent{Sym: KeyF8}
+			k = KeyDownEvent{Sym: KeyF8}
 		case 20:
-			return len(seq), KeyDownEvent{Sym: KeyF9}
+			k = KeyDownEvent{Sym: KeyF9}
 		case 21:
-			return len(seq), KeyDownEvent{Sym: KeyF10}
+			k = KeyDownEvent{Sym: KeyF10}
 		case 23:
-			return len(seq), KeyDownEvent{Sym: KeyF11}
+			k = KeyDownEvent{Sym: KeyF11}
 		case 24:
-			return len(seq), KeyDownEvent{Sym: KeyF12}
+			k = KeyDownEvent{Sym: KeyF12}
 		case 25:
-			return len(seq), KeyDownEvent{Sym: KeyF13}
+			k = KeyDownEvent{Sym: KeyF13}
 		case 26:
-			return len(seq), KeyDownEvent{Sym: KeyF14}
+			k = KeyDownEvent{Sym: KeyF14}
 		case 28:
-			return len(seq), KeyDownEvent{Sym: KeyF15}
+			k = KeyDownEvent{Sym: KeyF15}
 		case 29:
-			return len(seq), KeyDownEvent{Sym: KeyF16}
+			k = KeyDownEvent{Sym: KeyF16}
 		case 31:
-			return len(seq), KeyDownEvent{Sym: KeyF17}
+			k = KeyDownEvent{Sym: KeyF17}
 		case 32:
-			return len(seq), KeyDownEvent{Sym: KeyF18}
+			k = KeyDownEvent{Sym: KeyF18}
 		case 33:
-			return len(seq), KeyDownEvent{Sym: KeyF19}
+			k = KeyDownEvent{Sym: KeyF19}
 		case 34:
-			return len(seq), KeyDownEvent{Sym: KeyF20}
+			k = KeyDownEvent{Sym: KeyF20}
 		case 27:
 			// XTerm modifyOtherKeys 2
-			if len(params) != 3 {
-				return len(seq), UnknownCsiEvent(seq)
+			if e := parseXTermModifyOtherKeys(params); e != nil {
+				return len(seq), e
 			}
-			return len(seq), parseXTermModifyOtherKeys(params)
+			return len(seq), UnknownCsiEvent(seq)
 		case 200:
 			// bracketed-paste start
 			return len(seq), PasteStartEvent{}
@@ -304,6 +421,15 @@ func parseCsi(p []byte) (int, Event) {
 		default:
 			return len(seq), UnknownCsiEvent(seq)
 		}
+
+		// CSI <number> ; <modifiers> [: <kind>] ~
+		if len(params) > 1 {
+			k.Mod |= parseXTermModifier(params[1][0])
+			if len(params[1]) > 1 {
+				return len(seq), keyEventKind(params[1][1]).event(k)
+			}
+		}
+		return len(seq), k
 	default:
 		return len(seq), UnknownCsiEvent(seq)
 	}
@@ -323,6 +449,18 @@ func parseSs3(p []byte) (int, Event) {
 		i++
 	}
 
+	// Scan modifier digits i.e. SS3 <modifier> <func>
+	var mod uint
+	for ; i < len(p) && p[i] >= '0' && p[i] <= '9'; i++ {
+		mod *= 10
+		mod += uint(p[i] - '0')
+		seq = append(seq, p[i])
+	}
+
+	if i < len(p) && isCancel(p[i]) {
+		return i + 1, IgnoredEvent{}
+	}
+
 	// Scan a GL character
 	// A GL character is a single byte in the range 0x21-0x7E
 	// See https://vt100.net/docs/vt220-rm/chapter2.html#S2.3.2
@@ -333,74 +471,85 @@ func parseSs3(p []byte) (int, Event) {
 	// Add the GL character
 	seq = append(seq, p[i])
 
+	var k KeyDownEvent
 	switch p[i] {
 	case 'A':
-		return len(seq), KeyDownEvent{Sym: KeyUp}
+		k = KeyDownEvent{Sym: KeyUp}
 	case 'B':
-		return len(seq), KeyDownEvent{Sym: KeyDown}
+		k = KeyDownEvent{Sym: KeyDown}
 	case 'C':
-		return len(seq), KeyDownEvent{Sym: KeyRight}
+		k = KeyDownEvent{Sym: KeyRight}
 	case 'D':
-		return len(seq), KeyDownEvent{Sym: KeyLeft}
+		k = KeyDownEvent{Sym: KeyLeft}
+	case 'E':
+		k = KeyDownEvent{Sym: KeyBegin}
 	case 'F':
-		return len(seq), KeyDownEvent{Sym: KeyEnd}
+		k = KeyDownEvent{Sym: KeyEnd}
 	case 'H':
-		return len(seq), KeyDownEvent{Sym: KeyHome}
+		k = KeyDownEvent{Sym: KeyHome}
 	case 'P':
-		return len(seq), KeyDownEvent{Sym: KeyF1}
+		k = KeyDownEvent{Sym: KeyF1}
 	case 'Q':
-		return len(seq), KeyDownEvent{Sym: KeyF2}
+		k = KeyDownEvent{Sym: KeyF2}
 	case 'R':
-		return len(seq), KeyDownEvent{Sym: KeyF3}
+		k = KeyDownEvent{Sym: KeyF3}
 	case 'S':
-		return len(seq), KeyDownEvent{Sym: KeyF4}
+		k = KeyDownEvent{Sym: KeyF4}
+	case 'Z':
+		k = KeyDownEvent{Sym: KeyTab, Mod: Shift}
 	case 'a':
-		return len(seq), KeyDownEvent{Sym: KeyUp, Mod: Shift}
+		// URxvt reports ctrl+<arrow> as SS3 with a lowercase letter, and
+		// shift+<arrow> as CSI with a lowercase letter.
+		k = KeyDownEvent{Sym: KeyUp, Mod: Ctrl}
 	case 'b':
-		return len(seq), KeyDownEvent{Sym: KeyDown, Mod: Shift}
+		k = KeyDownEvent{Sym: KeyDown, Mod: Ctrl}
 	case 'c':
-		return len(seq), KeyDownEvent{Sym: KeyRight, Mod: Shift}
+		k = KeyDownEvent{Sym: KeyRight, Mod: Ctrl}
 	case 'd':
-		return len(seq), KeyDownEvent{Sym: KeyLeft, Mod: Shift}
+		k = KeyDownEvent{Sym: KeyLeft, Mod: Ctrl}
 	case 'M':
-		return len(seq), KeyDownEvent{Sym: KeyKpEnter}
+		k = KeyDownEvent{Sym: KeyKpEnter}
 	case 'X':
-		return len(seq), KeyDownEvent{Sym: KeyKpEqual}
+		k = KeyDownEvent{Sym: KeyKpEqual}
 	case 'j':
-		return len(seq), KeyDownEvent{Sym: KeyKpMul}
+		k = KeyDownEvent{Sym: KeyKpMul}
 	case 'k':
-		return len(seq), KeyDownEvent{Sym: KeyKpPlus}
+		k = KeyDownEvent{Sym: KeyKpPlus}
 	case 'l':
-		return len(seq), KeyDownEvent{Sym: KeyKpComma}
+		k = KeyDownEvent{Sym: KeyKpComma}
 	case 'm':
-		return len(seq), KeyDownEvent{Sym: KeyKpMinus}
+		k = KeyDownEvent{Sym: KeyKpMinus}
 	case 'n':
-		return len(seq), KeyDownEvent{Sym: KeyKpPeriod}
+		k = KeyDownEvent{Sym: KeyKpPeriod}
 	case 'o':
-		return len(seq), KeyDownEvent{Sym: KeyKpDiv}
+		k = KeyDownEvent{Sym: KeyKpDiv}
 	case 'p':
-		return len(seq), KeyDownEvent{Sym: KeyKp0}
+		k = KeyDownEvent{Sym: KeyKp0}
 	case 'q':
-		return len(seq), KeyDownEvent{Sym: KeyKp1}
+		k = KeyDownEvent{Sym: KeyKp1}
 	case 'r':
-		return len(seq), KeyDownEvent{Sym: KeyKp2}
+		k = KeyDownEvent{Sym: KeyKp2}
 	case 's':
-		return len(seq), KeyDownEvent{Sym: KeyKp3}
+		k = KeyDownEvent{Sym: KeyKp3}
 	case 't':
-		return len(seq), KeyDownEvent{Sym: KeyKp4}
+		k = KeyDownEvent{Sym: KeyKp4}
 	case 'u':
-		return len(seq), KeyDownEvent{Sym: KeyKp5}
+		k = KeyDownEvent{Sym: KeyKp5}
 	case 'v':
-		return len(seq), KeyDownEvent{Sym: KeyKp6}
+		k = KeyDownEvent{Sym: KeyKp6}
 	case 'w':
-		return len(seq), KeyDownEvent{Sym: KeyKp7}
+		k = KeyDownEvent{Sym: KeyKp7}
 	case 'x':
-		return len(seq), KeyDownEvent{Sym: KeyKp8}
+		k = KeyDownEvent{Sym: KeyKp8}
 	case 'y':
-		return len(seq), KeyDownEvent{Sym: KeyKp9}
+		k = KeyDownEvent{Sym: KeyKp9}
 	default:
 		return len(seq), UnknownSs3Event(seq)
 	}
+
+	// SS3 <modifier> <func>
+	k.Mod |= parseXTermModifier(mod)
+	return len(seq), k
 }
 
 func parseOsc(p []byte) (int, Event) {
@@ -417,26 +566,19 @@ func parseOsc(p []byte) (int, Event) {
 
 	// Scan a OSC sequence
 	// An OSC sequence is terminated by a BEL, ESC, or ST character
-	var start, end int
-	var dstart, dend int
-	for j := 0; i < len(p) && p[i] != ansi.BEL && p[i] != ansi.ESC && p[i] != ansi.ST; i, j = i+1, j+1 {
-		if end != 0 && dstart == 0 {
-			dstart = i
-		}
-		if j == 0 {
-			start = i
-		}
-		if p[i] == ';' {
-			end = i
-		}
+	start := i
+	for ; i < len(p) && p[i] != ansi.BEL && p[i] != ansi.ESC && p[i] != ansi.ST && !isCancel(p[i]); i++ {
 		seq = append(seq, p[i])
 	}
 
-	dend = i
+	end := i
 
 	if i >= len(p) {
 		return len(seq), UnknownEvent(seq)
 	}
+	if isCancel(p[i]) {
+		return i + 1, IgnoredEvent{}
+	}
 	seq = append(seq, p[i])
 
 	// Check 7-bit ST (string terminator) character
@@ -445,21 +587,10 @@ func parseOsc(p []byte) (int, Event) {
 		seq = append(seq, p[i])
 	}
 
-	if end <= start || dend <= dstart {
-		return len(seq), UnknownOscEvent(seq)
-	}
-
-	data := string(p[dstart:dend])
-	switch string(seq[start:end]) {
-	case "10":
-		return len(seq), ForegroundColorEvent{xParseColor(data)}
-	case "11":
-		return len(seq), BackgroundColorEvent{xParseColor(data)}
-	case "12":
-		return len(seq), CursorColorEvent{xParseColor(data)}
-	default:
-		return len(seq), UnknownOscEvent(seq)
+	if e := parseOscData(p[start:end]); e != nil {
+		return len(seq), e
 	}
+	return len(seq), UnknownOscEvent(seq)
 }
 
 // parseCtrl parses a control sequence that gets terminated by a ST character.
@@ -479,10 +610,14 @@ func parseCtrl(intro8, intro7 byte) func([]byte) (int, Event) {
 		// Scan control sequence
 		// Most common control sequence is terminated by a ST character
 		// ST is a 7-bit string terminator character is (ESC \)
-		for ; i < len(p) && p[i] != ansi.ST && p[i] != ansi.ESC; i++ {
+		for ; i < len(p) && p[i] != ansi.ST && p[i] != ansi.ESC && !isCancel(p[i]); i++ {
 			seq = append(seq, p[i])
 		}
 
+		if i < len(p) && isCancel(p[i]) {
+			return i + 1, IgnoredEvent{}
+		}
+
 		if i >= len(p) {
 			switch intro8 {
 			case ansi.DCS:
@@ -513,107 +648,31 @@ func parseCtrl(intro8, intro7 byte) func([]byte) (int, Event) {
 }
 
 func parseDcs(p []byte) (int, Event) {
-	// DCS sequences are introduced by DCS (0x90) or ESC P (0x1b 0x50)
-	var seq []byte
-	var i int
-	if p[i] == ansi.DCS || p[i] == ansi.ESC {
-		seq = append(seq, p[i])
-		i++
-	}
-	if i < len(p) && p[i-1] == ansi.ESC && p[i] == 'P' {
-		seq = append(seq, p[i])
-		i++
-	}
-
-	// Scan parameter bytes in the range 0x30-0x3F
-	var start, end int // start and end of the parameter bytes
-	for j := 0; i < len(p) && p[i] >= 0x30 && p[i] <= 0x3F; i, j = i+1, j+1 {
-		if j == 0 {
-			start = i
+	n, dcs, ok := scanDcs(p)
+	if !ok {
+		// Cancelled sequences aren't reported.
+		if n > 0 && isCancel(p[n-1]) {
+			return n, IgnoredEvent{}
 		}
-		seq = append(seq, p[i])
-	}
-
-	end = i
-
-	// Scan intermediate bytes in the range 0x20-0x2F
-	var istart, iend int
-	for j := 0; i < len(p) && p[i] >= 0x20 && p[i] <= 0x2F; i, j = i+1, j+1 {
-		if j == 0 {
-			istart = i
-		}
-		seq = append(seq, p[i])
+		return n, UnknownEvent(p[:n])
 	}
 
-	iend = i
-
-	// Final byte
-	var final byte
-
-	// Scan final byte in the range 0x40-0x7E
-	if i >= len(p) || p[i] < 0x40 || p[i] > 0x7E {
-		return len(seq), UnknownEvent(seq)
-	}
-	// Add the final byte
-	final = p[i]
-	seq = append(seq, p[i])
-
-	if i+1 >= len(p) {
-		return len(seq), UnknownEvent(seq)
-	}
-
-	// Collect data bytes until a ST character is found
-	// data bytes are in the range of 0x08-0x0D and 0x20-0x7F
-	// but we don't care about the actual values for now
-	var data []byte
-	for i++; i < len(p) && p[i] != ansi.ST && p[i] != ansi.ESC; i++ {
-		data = append(data, p[i])
-		seq = append(seq, p[i])
-	}
-
-	if i >= len(p) {
-		return len(seq), UnknownEvent(seq)
-	}
-
-	seq = append(seq, p[i])
-
-	// Check 7-bit ST (string terminator) character
-	if len(p) > i+1 && p[i] == ansi.ESC && p[i+1] == '\\' {
-		i++
-		seq = append(seq, p[i])
-	}
-
-	switch final {
-	case 'r':
-		inters := p[istart:iend] // intermediates
-		if len(inters) == 0 {
-			return len(seq), UnknownDcsEvent(seq)
-		}
-		switch inters[0] {
-		case '+':
-			// XTGETTCAP responses
-			params := ansi.Params(p[start:end])
-			if len(params) == 0 {
-				return len(seq), UnknownDcsEvent(seq)
-			}
+	return n, parseDcsData(dcs)
+}
 
-			switch params[0][0] {
-			case 0, 1:
-				tc := parseTermcap(data)
-				// XXX: some terminals like KiTTY report invalid responses with
-				// their queries i.e. sending a query for "Tc" using "\x1bP+q5463\x1b\\"
-				// returns "\x1bP0+r5463\x1b\\".
-				// The specs says that invalid responses should be in the form of
-				// DCS 0 + r ST "\x1bP0+r\x1b\\"
-				//
-				// See: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Operating-System-Commands
-				tc.IsValid = params[0][0] == 1
-				return len(seq), tc
-			}
+// isDigits reports whether b is a non-empty run of decimal digits.
+func isDigits(b []byte) bool {
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
 		}
 	}
+	return len(b) > 0
+}
 
-	return len(seq), UnknownDcsEvent(seq)
+// isCancel reports whether the byte cancels a control sequence in progress.
+func isCancel(b byte) bool {
+	return b == ansi.CAN || b == ansi.SUB
 }
 
 func parseApc(p []byte) (int, Event) {
@@ -632,7 +691,7 @@ func parseUtf8(p []byte) (int, Event) {
 func parseCtrl0(b byte) Event {
 	switch b {
 	case ansi.NUL:
-		return KeyDownEvent{Sym: KeySpace, Mod: Ctrl}
+		return KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl}
 	case ansi.SOH:
 		return KeyDownEvent{Rune: 'a', Mod: Ctrl}
 	case ansi.STX:
@@ -0,0 +1,114 @@
+package input
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestParseOscEmptyPayload(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		// Window title and icon label reports
+		{"\x1b]l\x1b\\", WindowLabelEvent{}},
+		{"\x1b]l\a", WindowLabelEvent{}},
+		{"\x9dl\x9c", WindowLabelEvent{}},
+		{"\x1b]L\x1b\\", IconLabelEvent{}},
+		{"\x1b]L\a", IconLabelEvent{}},
+		{"\x1b]lfoo bar\x1b\\", WindowLabelEvent{Label: "foo bar"}},
+		{"\x1b]Lfoo;bar\a", IconLabelEvent{Label: "foo;bar"}},
+
+		// Color reports
+		{"\x1b]10;\x1b\\", UnknownOscEvent("\x1b]10;\x1b\\")},
+		{"\x1b]10\a", UnknownOscEvent("\x1b]10\a")},
+		{"\x1b]11;\a", UnknownOscEvent("\x1b]11;\a")},
+		{"\x1b]12;\a", UnknownOscEvent("\x1b]12;\a")},
+		{"\x1b]11;rgb:ffff/0000/0000\a", BackgroundColorEvent{color.RGBA{R: 0xff, A: 0xff}}},
+
+		// Working directory
+		{"\x1b]7;\x1b\\", UnknownOscEvent("\x1b]7;\x1b\\")},
+		{"\x1b]7\a", UnknownOscEvent("\x1b]7\a")},
+
+		// No command at all
+		{"\x1b]\x1b\\", UnknownOscEvent("\x1b]\x1b\\")},
+		{"\x1b]\a", UnknownOscEvent("\x1b]\a")},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+		}
+	}
+}
+
+func TestParseCancelledSequences(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []Event
+	}{
+		{"\x1b[12\x18", nil},
+		{"\x1b[12\x18a", []Event{KeyDownEvent{Rune: 'a'}}},
+		{"\x1b[1;5\x1a\x1b[A", []Event{KeyDownEvent{Sym: KeyUp}}},
+		{"\x9b12\x18a", []Event{KeyDownEvent{Rune: 'a'}}},
+		{"\x1bO\x18a", []Event{KeyDownEvent{Rune: 'a'}}},
+		{"\x1b]11;rgb:ffff\x18a", []Event{KeyDownEvent{Rune: 'a'}}},
+		{"\x1bP1+r5463\x1a\x1b[B", []Event{KeyDownEvent{Sym: KeyDown}}},
+		{"\x1bP+\x18a", []Event{KeyDownEvent{Rune: 'a'}}},
+		{"\x1b_Gi=1\x18a", []Event{KeyDownEvent{Rune: 'a'}}},
+
+		// CAN and SUB outside of a sequence are still keys.
+		{"\x18", []Event{KeyDownEvent{Rune: 'x', Mod: Ctrl}}},
+		{"a\x1a", []Event{KeyDownEvent{Rune: 'a'}, KeyDownEvent{Rune: 'z', Mod: Ctrl}}},
+	}
+
+	for _, c := range cases {
+		if got := DecodeString(c.in); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: expected %v, got %v", c.in, c.want, got)
+		}
+	}
+
+	// The cancelled sequence is consumed including the cancelling character.
+	if n, e := ParseSequence([]byte("\x1b[12\x18a")); n != 5 || e != (IgnoredEvent{}) {
+		t.Errorf("expected 5 bytes and an ignored event, got %d and %v", n, e)
+	}
+}
+
+func TestReadNoNilEvents(t *testing.T) {
+	in := []string{
+		"\x1b[12\x18",                        // cancelled CSI
+		"\x1b]11;rgb:ff\x1a",                 // cancelled OSC
+		"\x1bP+r\x18",                        // cancelled DCS
+		"\x1b[20;58;0;1;128;1_",              // win32-input-mode Caps Lock press
+		"\x1b[65;30;65;1;128;3_",             // repeated key with Caps Lock on
+		"\x1b]10;rgb:f/f/f;11;rgb:0/0/0\x07", // batched colors
+		"\x1b[<65;1;1M\x1b[999;9x\x1b]999;?\x07\x1b_G\x1b\\",
+		"\xe2\x94",
+	}
+
+	for _, seq := range in {
+		if n, e := ParseSequence([]byte(seq)); n > 0 && e == nil {
+			t.Errorf("%q: unexpected nil event for %d bytes", seq, n)
+		}
+	}
+
+	for _, flags := range []int{0, FlagCollapseRepeats, FlagMouseClicks | FlagMouseDelta, FlagKeyReleaseOnly} {
+		events := readEvents(t, flags, in...)
+		if len(events) == 0 {
+			t.Errorf("flags %b: expected events", flags)
+		}
+		for i, e := range events {
+			switch e.(type) {
+			case nil, IgnoredEvent:
+				t.Errorf("flags %b: unexpected %T event at %d in %v", flags, e, i, events)
+			case MultiEvent:
+				t.Errorf("flags %b: unexpected multi event at %d in %v", flags, i, events)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseKey parses a key string such as "ctrl+alt+a", "shift+tab", or "space"
+// into a KeyDownEvent. It understands the same names KeyDownEvent.String
+// produces. Modifiers are separated by '+' and the key comes last. Modifier
+// and key symbol names are case insensitive, while runes are not i.e.
+// "Ctrl+Enter" is "ctrl+enter" but "ctrl+A" is not "ctrl+a".
+//
+// It returns an error wrapping ErrInvalidKey for unknown names, repeated
+// modifiers, and strings without a key.
+//
+// Some Ctrl combinations can't be told apart by the terminal because they
+// send the same control code. ParseKey resolves these to the event the
+// driver would report, so the result can be compared with decoded events:
+//
+//	ctrl+/  sends US (0x1f), same as ctrl+_
+//	ctrl+?  sends DEL (0x7f), same as backspace
+func ParseKey(s string) (KeyDownEvent, error) {
+	var k KeyDownEvent
+
+	name := s
+	for {
+		// A leading '+' is the key itself i.e. "ctrl++".
+		i := strings.IndexByte(name, '+')
+		if i < 1 {
+			break
+		}
+
+		mod, ok := modNames[strings.ToLower(name[:i])]
+		if !ok {
+			return KeyDownEvent{}, fmt.Errorf("%w: unknown modifier %q in %q", ErrInvalidKey, name[:i], s)
+		}
+		if k.Mod&mod != 0 {
+			return KeyDownEvent{}, fmt.Errorf("%w: repeated modifier %q in %q", ErrInvalidKey, name[:i], s)
+		}
+
+		k.Mod |= mod
+		name = name[i+1:]
+	}
+
+	if name == "" {
+		return KeyDownEvent{}, fmt.Errorf("%w: missing key in %q", ErrInvalidKey, s)
+	}
+
+	if sym, ok := keySymNames[strings.ToLower(name)]; ok {
+		k.Sym = sym
+		if sym == KeySpace {
+			k.Rune = ' '
+		}
+	} else if r, w := utf8.DecodeRuneInString(name); r != utf8.RuneError && w == len(name) {
+		k.Rune = r
+	} else {
+		return KeyDownEvent{}, fmt.Errorf("%w: unknown key %q in %q", ErrInvalidKey, name, s)
+	}
+
+	// Resolve Ctrl aliases.
+	if k.Mod.IsCtrl() {
+		switch k.Rune {
+		case '/':
+			k.Rune = '_'
+		case '?':
+			k.Rune = 0
+			k.Sym = KeyBackspace
+			k.Mod &^= Ctrl
+		}
+	}
+
+	return k, nil
+}
+
+// modNames is the reverse of modStrings.
+var modNames = func() map[string]Mod {
+	m := make(map[string]Mod, len(modStrings))
+	for _, n := range modStrings {
+		m[n.name] = n.mod
+	}
+	return m
+}()
+
+// keySymNames is the reverse of keySymString.
+var keySymNames = func() map[string]KeySym {
+	m := make(map[string]KeySym, len(keySymString))
+	for k, v := range keySymString {
+		m[v] = k
+	}
+	return m
+}()
@@ -0,0 +1,100 @@
+package input
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	cases := []struct {
+		s    string
+		want KeyDownEvent
+	}{
+		{"a", KeyDownEvent{Rune: 'a'}},
+		{"ctrl+a", KeyDownEvent{Rune: 'a', Mod: Ctrl}},
+		{"ctrl+alt+a", KeyDownEvent{Rune: 'a', Mod: Ctrl | Alt}},
+		{"shift+tab", KeyDownEvent{Sym: KeyTab, Mod: Shift}},
+		{"space", KeyDownEvent{Sym: KeySpace, Rune: ' '}},
+		{"f12", KeyDownEvent{Sym: KeyF12}},
+		{"ctrl++", KeyDownEvent{Rune: '+', Mod: Ctrl}},
+		{"+", KeyDownEvent{Rune: '+'}},
+		{"é", KeyDownEvent{Rune: 'é'}},
+		{"alt+enter", KeyDownEvent{Sym: KeyEnter, Mod: Alt}},
+		{"shift+f3", KeyDownEvent{Sym: KeyF3, Mod: Shift}},
+		{"up", KeyDownEvent{Sym: KeyUp}},
+		{"Ctrl+Shift+Up", KeyDownEvent{Sym: KeyUp, Mod: Ctrl | Shift}},
+		{"ctrl+A", KeyDownEvent{Rune: 'A', Mod: Ctrl}},
+		{"scrolllock+a", KeyDownEvent{Rune: 'a', Mod: ScrollLock}},
+	}
+
+	for _, c := range cases {
+		k, err := ParseKey(c.s)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", c.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(k, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.s, c.want, k)
+		}
+	}
+}
+
+func TestParseKeyCtrlAliases(t *testing.T) {
+	cases := []struct {
+		s   string
+		seq string
+	}{
+		{"ctrl+/", "\x1f"},
+		{"ctrl+_", "\x1f"},
+		{"ctrl+alt+/", "\x1b\x1f"},
+		{"ctrl+?", "\x7f"},
+		{"backspace", "\x7f"},
+	}
+
+	for _, c := range cases {
+		k, err := ParseKey(c.s)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", c.s, err)
+			continue
+		}
+		_, e := ParseSequence([]byte(c.seq))
+		if !reflect.DeepEqual(k, e) {
+			t.Errorf("%q: expected %#v, got %#v", c.s, e, k)
+		}
+	}
+}
+
+func TestParseKeyInvalid(t *testing.T) {
+	for _, s := range []string{
+		"", "ctrl+", "foo+a", "notakey", "ctrl+ctrl+a", "alt+ctrl+Alt+a",
+		"ctrl+shift", "ctrl+alt+", "+a",
+	} {
+		if _, err := ParseKey(s); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%q: expected ErrInvalidKey, got %v", s, err)
+		}
+	}
+}
+
+func TestParseKeyString(t *testing.T) {
+	for sym := range keySymString {
+		for _, mod := range []Mod{0, Ctrl, Alt | Shift, Ctrl | Alt | Shift | Meta | Hyper | Super} {
+			k := KeyDownEvent{Sym: sym, Mod: mod}
+			if sym == KeySpace {
+				k.Rune = ' '
+			}
+			if keySymMod(sym) != 0 {
+				// Modifier keys don't report their own modifier.
+				continue
+			}
+			got, err := ParseKey(k.String())
+			if err != nil {
+				t.Errorf("%q: unexpected error: %v", k.String(), err)
+				continue
+			}
+			if !reflect.DeepEqual(got, k) {
+				t.Errorf("%q: expected %#v, got %#v", k.String(), k, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,527 @@
+package input
+
+import (
+	"image"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/exp/term/ansi"
+)
+
+// Parser decodes terminal input bytes into events. Unlike Driver, it doesn't
+// read from the terminal, use it when the input is already available e.g.
+// from a WASM terminal or a network protocol. It keeps track of the
+// bracketed paste and mouse tracking state between calls.
+//
+// A Parser isn't safe for concurrent use.
+type Parser struct {
+	table map[string]KeyDownEvent
+	trie  *keyTrie // the key table compiled for prefix matching
+
+	// c0 holds the C0 control keys that override the defaults, see
+	// SetC0Handler.
+	c0 map[byte]KeyDownEvent
+
+	// keys holds the key sequences registered with RegisterKey, and
+	// removed the ones removed with UnregisterKey. They're applied after the
+	// built-in key sequences.
+	keys    map[string]KeyDownEvent
+	removed map[string]struct{}
+
+	// excluded holds the key sequences of other terminal profiles, see
+	// Profile.
+	excluded map[string]struct{}
+
+	term string // the $TERM name to use
+
+	// profile is the terminal key profile. It's detected from term when
+	// empty.
+	profile Profile
+
+	// surrogates holds the Win32 input mode key events of UTF-16 high
+	// surrogates waiting for their low surrogate pair.
+	surrogates surrogates
+
+	// paste is the bracketed paste mode buffer.
+	// When nil, bracketed paste mode is disabled.
+	paste []byte
+
+	// pending holds an incomplete sequence waiting for more bytes.
+	pending []byte
+
+	// prevMouse is the position of the last mouse event. It's used to compute
+	// mouse deltas when FlagMouseDelta is set.
+	prevMouse *image.Point
+
+	// clicks keeps track of consecutive mouse presses when FlagMouseClicks
+	// is set.
+	clicks        clickState
+	clickInterval time.Duration
+
+	// modifyOtherKeys is the active XTerm modifyOtherKeys mode. It's set by
+	// SetModifyOtherKeys and by the ModifyOtherKeysEvent reports.
+	modifyOtherKeys ModifyOtherKeysEvent
+
+	// now returns the current time. It's replaced in tests.
+	now func() time.Time
+
+	// flags to control the behavior of the parser.
+	flags int
+}
+
+// NewParser returns a new input parser. It takes the same options as
+// NewDriver, the options that only apply to reading e.g. WithEscTimeout are
+// ignored.
+func NewParser(opts ...Option) (*Parser, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	p := newParser(o.term, o.flags)
+	o.apply(p)
+	return p, nil
+}
+
+// newParser returns a new parser for the given terminal name and flags.
+func newParser(term string, flags int) *Parser {
+	p := new(Parser)
+	p.init(term, flags)
+	return p
+}
+
+// init initializes the parser and populates the key sequences table.
+func (p *Parser) init(term string, flags int) {
+	p.flags = flags
+	p.term = term
+	p.clickInterval = DefaultClickInterval
+	p.now = time.Now
+	p.registerKeys(flags)
+}
+
+// Parse decodes the input bytes into events. It returns the events decoded
+// so far and the trailing bytes of an incomplete sequence, e.g. a rune, a CSI
+// sequence, or an OSC reply split across reads, to prepend to the next input.
+// The rest is always a copy, it doesn't alias b.
+//
+// A trailing ESC is decoded as the Escape key. Use Flush when no more input
+// is coming to decode the rest as is.
+func (p *Parser) Parse(b []byte) (events []Event, rest []byte) {
+	events = p.decode(b)
+	rest, p.pending = p.pending, nil
+	return events, rest
+}
+
+// Flush decodes the rest of the input returned by Parse when no more input is
+// coming e.g. at the end of a recording. A key sequence is reported as the
+// key, anything else as an UnknownEvent. An unterminated bracketed paste is
+// reported as is, followed by a PasteEndEvent.
+func (p *Parser) Flush(rest []byte) []Event {
+	p.pending = append(p.pending, rest...)
+	return p.flush()
+}
+
+// decode parses the given input buffer into events. It keeps track of the
+// bracketed-paste state between calls.
+func (p *Parser) decode(buf []byte) []Event {
+	// Prepend any incomplete sequence from the previous call.
+	if len(p.pending) > 0 {
+		buf = append(p.pending, buf...)
+		p.pending = nil
+	}
+
+	// Lookup table first
+	if p.paste == nil {
+		if k, ok := p.table[string(buf)]; ok {
+			return p.postprocess(nil, k)
+		}
+	}
+
+	var events []Event
+	var i int
+	for i < len(buf) {
+		if p.flags&FlagMeta8Bit != 0 && p.paste == nil && buf[i] >= 0x80 {
+			events = p.postprocess(events, p.meta8Bit(buf[i]))
+			i++
+			continue
+		}
+
+		if p.paste == nil && isIncompleteUtf8(buf[i:]) {
+			// Wait for the rest of the rune, the following continuation
+			// bytes must not be mistaken for C1 controls.
+			p.pending = append([]byte(nil), buf[i:]...)
+			break
+		}
+
+		// Prefer the longest key sequence. The table honors the driver flags
+		// and knows about sequences the parser doesn't recognize e.g. URxvt
+		// modifier keys and terminfo keys.
+		var n int
+		var k KeyDownEvent
+		var isKey, isLeaf bool
+		if p.paste == nil {
+			n, k, isKey, isLeaf = p.trie.match(buf[i:])
+			if !isKey && p.flags&FlagNoC1 == 0 {
+				// Terminals in 8-bit mode send C1 controls e.g. CSI (0x9b)
+				// instead of the 7-bit ESC prefixed sequences in the table.
+				n, k, isKey, isLeaf = p.trie.matchC1(buf[i:])
+			}
+		}
+
+		var nb int
+		var ev Event
+		if isKey && isLeaf {
+			// No longer key sequence starts with this one, and a key sequence
+			// is always complete. There's no need to parse it.
+			nb, ev = n, k
+		} else {
+			nb, ev = parseSequence(buf[i:], p.flags)
+			if isKey && n >= nb {
+				nb, ev = n, k
+			} else if p.isExcluded(buf[i : i+nb]) {
+				ev = unknownSequence(buf[i : i+nb])
+			}
+		}
+
+		if p.paste == nil && i+nb == len(buf) && !(isKey && n == nb) && isIncompleteSequence(buf[i:], p.flags) {
+			// Wait for the rest of the sequence.
+			p.pending = append([]byte(nil), buf[i:]...)
+			break
+		}
+
+		// Handle bracketed-paste
+		if p.paste != nil {
+			if _, ok := ev.(PasteEndEvent); !ok {
+				if isPasteEndPrefix(buf[i:]) {
+					// The paste end marker might be split across reads,
+					// wait for the rest of it.
+					p.pending = append([]byte(nil), buf[i:]...)
+					break
+				}
+				p.paste = append(p.paste, buf[i])
+				i++
+				continue
+			}
+		}
+
+		switch ev.(type) {
+		case PasteStartEvent:
+			p.paste = []byte{}
+		case PasteEndEvent:
+			if p.paste == nil {
+				// A paste end without a matching start, we're either out of
+				// sync or joined midstream. Report it as an unknown sequence.
+				events = append(events, UnknownCsiEvent(buf[i:i+nb]))
+				i += nb
+				continue
+			}
+
+			events = append(events, p.pasteEvent())
+		case ModifyOtherKeysEvent:
+			p.modifyOtherKeys = ev.(ModifyOtherKeysEvent)
+		case nil, IgnoredEvent:
+			// Skip cancelled sequences and ignored key records.
+			if nb == 0 {
+				nb = 1
+			}
+			i += nb
+			continue
+		default:
+			// Look up key sequences reporting the event kind.
+			if !isKey || n < nb {
+				if k, ok := p.lookupEventKind(buf[i : i+nb]); ok {
+					ev = k
+				}
+			}
+			if k, ok := ev.(KeyDownEvent); ok && isModifyOtherKeys(buf[i:i+nb]) {
+				ev = p.modifyOtherKeysKey(k)
+			}
+		}
+
+		events = p.appendEvent(events, ev)
+		i += nb
+	}
+
+	return events
+}
+
+// pasteEvent decodes the captured paste data into runes and resets the paste
+// buffer.
+func (p *Parser) pasteEvent() Event {
+	var paste []rune
+	for len(p.paste) > 0 {
+		r, w := utf8.DecodeRune(p.paste)
+		if r != utf8.RuneError {
+			paste = append(paste, r)
+		}
+		p.paste = p.paste[w:]
+	}
+	p.paste = nil // reset the buffer
+	return PasteEvent(paste)
+}
+
+// flush returns the pending incomplete sequence, if any, decoded as is e.g.
+// an UnknownEvent. Pending bytes that are a key on their own, e.g. a held
+// ESC, are reported as the key. An unterminated paste is reported as is,
+// followed by a PasteEndEvent. Use it when no more input is coming.
+func (p *Parser) flush() []Event {
+	// Unpaired high surrogates are reported as U+FFFD.
+	var events []Event
+	for _, e := range p.surrogates.flush() {
+		events = p.postprocess(events, e)
+	}
+
+	if p.paste != nil {
+		p.paste = append(p.paste, p.pending...)
+		p.pending = nil
+		return append(events, p.pasteEvent(), PasteEndEvent{})
+	}
+
+	if len(p.pending) == 0 {
+		return events
+	}
+
+	// A held key e.g. a lone ESC is a complete key on its own. Decode other
+	// incomplete sequences as is, string sequences terminated by a lone ESC
+	// are complete.
+	var e Event = UnknownEvent(p.pending)
+	if k, ok := p.table[string(p.pending)]; ok {
+		e = k
+	} else if n, ev := parseSequence(p.pending, p.flags); n == len(p.pending) && ev != nil {
+		e = ev
+	}
+	p.pending = nil
+
+	for _, e := range FlattenEvents(e) {
+		events = p.postprocess(events, e)
+	}
+	return events
+}
+
+// pasteEnd is the bracketed-paste end marker.
+const pasteEnd = "\x1b[201~"
+
+// isPasteEndPrefix reports whether the buffer is an incomplete paste end
+// marker.
+func isPasteEndPrefix(b []byte) bool {
+	return len(b) > 0 && len(b) < len(pasteEnd) && strings.HasPrefix(pasteEnd, string(b))
+}
+
+// isIncompleteUtf8 reports whether the buffer is a multi-byte UTF-8 rune
+// missing its continuation bytes.
+func isIncompleteUtf8(b []byte) bool {
+	return len(b) > 0 && b[0] >= utf8.RuneSelf && !utf8.FullRune(b)
+}
+
+// isIncompleteSequence reports whether the buffer is a CSI, SS3, OSC, DCS,
+// or APC sequence missing its final byte or string terminator. A trailing ESC
+// in a string sequence might be the start of a 7-bit ST.
+func isIncompleteSequence(b []byte, flags int) bool {
+	var intro byte
+	switch {
+	case len(b) > 1 && b[0] == ansi.ESC:
+		intro, b = b[1], b[2:]
+	case len(b) > 0 && b[0] >= 0x80 && b[0] <= 0x9f && flags&FlagNoC1 == 0:
+		// C1 controls are the 7-bit introducer + 0x40.
+		intro, b = b[0]-0x40, b[1:]
+	default:
+		return false
+	}
+
+	switch intro {
+	case '[':
+		// Parameter and intermediate bytes only. Like parseCsi, a '$' is
+		// the final byte of URxvt shifted keys e.g. CSI 7 $.
+		for _, c := range b {
+			if c < 0x20 || c > 0x3f || c == '$' {
+				return false
+			}
+		}
+		return true
+	case 'O':
+		return len(b) == 0
+	case ']', 'P', '_':
+		for i, c := range b {
+			switch {
+			case c == ansi.ST, c == ansi.BEL && intro == ']', isCancel(c):
+				return false
+			case c == ansi.ESC:
+				return i == len(b)-1
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// meta8Bit decodes an 8-bit meta character, a byte with the high bit set, as
+// Alt + <key>.
+func (p *Parser) meta8Bit(b byte) Event {
+	b &^= 0x80
+	k, ok := p.table[string(b)]
+	if !ok {
+		k = KeyDownEvent{Rune: rune(b)}
+	}
+	k.Mod |= Alt
+	return k
+}
+
+// appendEvent appends the events to report for a decoded event. MultiEvents
+// are flattened, surrogate key events are paired, and the mouse and parser
+// flags are applied.
+func (p *Parser) appendEvent(events []Event, e Event) []Event {
+	switch e := e.(type) {
+	case nil, IgnoredEvent:
+		return events
+	case MultiEvent:
+		for _, e := range e {
+			events = p.appendEvent(events, e)
+		}
+		return events
+	}
+
+	first, second := p.surrogates.combine(e)
+	for _, e := range [...]Event{first, second} {
+		if e != nil {
+			events = p.postprocess(events, p.mouseClicks(p.mouseDelta(e)))
+		}
+	}
+	return events
+}
+
+// postprocess applies the parser flags to a decoded event and appends the
+// events to report, which might be none.
+func (p *Parser) postprocess(events []Event, e Event) []Event {
+	if !p.keepKey(e) {
+		return events
+	}
+	return p.expandRepeats(events, p.normalizeShift(e))
+}
+
+// normalizeShift reports shift-modified printable keys as their shifted rune
+// without the Shift modifier when FlagNormalizeShift is set.
+func (p *Parser) normalizeShift(e Event) Event {
+	if p.flags&FlagNormalizeShift == 0 {
+		return e
+	}
+
+	switch e := e.(type) {
+	case KeyDownEvent:
+		k := key(e)
+		normalizeShift(&k)
+		return KeyDownEvent(k)
+	case KeyUpEvent:
+		k := key(e)
+		normalizeShift(&k)
+		return KeyUpEvent(k)
+	}
+
+	return e
+}
+
+func normalizeShift(k *key) {
+	if !k.Mod.IsShift() || k.Sym != KeyNone || !unicode.IsPrint(k.Rune) {
+		return
+	}
+
+	switch {
+	case k.ShiftedRune != 0:
+		// Kitty reports the shifted key when ReportAlternateKeys is set.
+		k.Rune = k.ShiftedRune
+		k.ShiftedRune = 0
+	case unicode.IsLetter(k.Rune):
+		k.Rune = unicode.ToUpper(k.Rune)
+	default:
+		// We can't know the shifted rune of non-letters without the keyboard
+		// layout.
+		return
+	}
+
+	k.Mod &^= Shift
+}
+
+// expandRepeats appends a key event with a repeat count as one event per
+// repeat unless FlagCollapseRepeats is set.
+func (p *Parser) expandRepeats(events []Event, e Event) []Event {
+	if p.flags&FlagCollapseRepeats != 0 {
+		return append(events, e)
+	}
+
+	var k key
+	switch e := e.(type) {
+	case KeyDownEvent:
+		k = key(e)
+	case KeyUpEvent:
+		k = key(e)
+	default:
+		return append(events, e)
+	}
+
+	if k.RepeatCount <= 1 {
+		return append(events, e)
+	}
+
+	n := k.RepeatCount
+	k.RepeatCount = 0
+	for i := 0; i < n; i++ {
+		if _, ok := e.(KeyUpEvent); ok {
+			events = append(events, KeyUpEvent(k))
+		} else {
+			events = append(events, KeyDownEvent(k))
+		}
+	}
+
+	return events
+}
+
+// keepKey reports whether the event should be reported based on the key
+// press and release flags.
+func (p *Parser) keepKey(e Event) bool {
+	switch e.(type) {
+	case KeyDownEvent:
+		return p.flags&FlagKeyPressOnly != 0 || p.flags&FlagKeyReleaseOnly == 0
+	case KeyUpEvent:
+		return p.flags&FlagKeyPressOnly == 0
+	}
+	return true
+}
+
+// mouseDelta fills in the DX and DY fields of mouse events with the distance
+// the mouse moved since the last mouse event. It does nothing unless
+// FlagMouseDelta is set.
+func (p *Parser) mouseDelta(e Event) Event {
+	if p.flags&FlagMouseDelta == 0 {
+		return e
+	}
+
+	switch e := e.(type) {
+	case MouseDownEvent:
+		// Start over on every button press.
+		p.prevMouse = &image.Point{X: e.X, Y: e.Y}
+		return e
+	case MouseUpEvent:
+		m := mouse(e)
+		p.trackMouse(&m)
+		return MouseUpEvent(m)
+	case MouseMoveEvent:
+		m := mouse(e)
+		p.trackMouse(&m)
+		return MouseMoveEvent(m)
+	}
+
+	return e
+}
+
+func (p *Parser) trackMouse(m *mouse) {
+	if p.prevMouse != nil {
+		m.DX = m.X - p.prevMouse.X
+		m.DY = m.Y - p.prevMouse.Y
+	}
+	p.prevMouse = &image.Point{X: m.X, Y: m.Y}
+}
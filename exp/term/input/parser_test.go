@@ -0,0 +1,156 @@
+package input
+
+import (
+	"errors"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+// parseChunks parses the input chunks with the parser the way a caller
+// feeding it input would, prepending the rest of each call to the next
+// chunk, and flushes the rest at the end.
+func parseChunks(p *Parser, chunks ...string) []Event {
+	var events []Event
+	var rest []byte
+	for _, c := range chunks {
+		var evs []Event
+		evs, rest = p.Parse(append(rest, c...))
+		events = append(events, evs...)
+	}
+	return append(events, p.Flush(rest)...)
+}
+
+func TestParserParse(t *testing.T) {
+	cases := []struct {
+		name   string
+		chunks []string
+		want   []Event
+	}{
+		{
+			"keys",
+			[]string{"a\x1b[A", "\x1bb"},
+			[]Event{KeyDownEvent{Rune: 'a'}, KeyDownEvent{Sym: KeyUp}, KeyDownEvent{Rune: 'b', Mod: Alt}},
+		},
+		{
+			"split rune",
+			[]string{"a\xe2\x94", "\x9b"},
+			[]Event{KeyDownEvent{Rune: 'a'}, KeyDownEvent{Rune: '┛'}},
+		},
+		{
+			"split mouse",
+			[]string{"\x1b[<0;10", ";5M"},
+			[]Event{MouseDownEvent{X: 9, Y: 4, Button: MouseButtonLeft}},
+		},
+		{
+			"split paste",
+			[]string{"\x1b[200~he", "llo\x1b[2", "01~"},
+			[]Event{PasteStartEvent{}, PasteEvent("hello"), PasteEndEvent{}},
+		},
+		{
+			"split csi",
+			[]string{"\x1b[1;5", "A", "\x9b1;", "3B"},
+			[]Event{KeyDownEvent{Sym: KeyUp, Mod: Ctrl}, KeyDownEvent{Sym: KeyDown, Mod: Alt}},
+		},
+		{
+			"split ss3",
+			[]string{"\x1bO", "P"},
+			[]Event{KeyDownEvent{Sym: KeyF1}},
+		},
+		{
+			"split osc",
+			[]string{"\x1b]11;rgb:ff", "ff/0000/0000", "\x1b", "\\a"},
+			[]Event{BackgroundColorEvent{Color: color.RGBA{R: 0xff, A: 0xff}}, KeyDownEvent{Rune: 'a'}},
+		},
+		{
+			"split dcs",
+			[]string{"\x1bP>|x", "term\x1b\\"},
+			[]Event{TerminalVersionEvent{Name: "xterm"}},
+		},
+		{
+			"split apc",
+			[]string{"\x1b_Gi=1", "\x1b\\"},
+			[]Event{UnknownApcEvent("\x1b_Gi=1\x1b\\")},
+		},
+		{
+			"unterminated csi",
+			[]string{"\x1b[1;5"},
+			[]Event{UnknownEvent("\x1b[1;5")},
+		},
+		{
+			"osc terminated by esc",
+			[]string{"\x1b]11;rgb:ff/00/00\x1b"},
+			[]Event{BackgroundColorEvent{Color: color.RGBA{R: 0xff, A: 0xff}}},
+		},
+		{
+			"unterminated paste",
+			[]string{"\x1b[200~hi"},
+			[]Event{PasteStartEvent{}, PasteEvent("hi"), PasteEndEvent{}},
+		},
+		{
+			"truncated rune",
+			[]string{"\xe2\x94"},
+			[]Event{UnknownEvent("\xe2\x94")},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := NewParser()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := parseChunks(p, c.chunks...); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestParserRest(t *testing.T) {
+	p := newParser("", 0)
+	in := []byte("a\x1b[<0;1")
+	events, rest := p.Parse(in)
+	if want := []Event{KeyDownEvent{Rune: 'a'}}; !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+	if string(rest) != "\x1b[<0;1" {
+		t.Errorf("expected the incomplete mouse sequence, got %q", rest)
+	}
+
+	// The rest doesn't alias the input.
+	in[2] = 'x'
+	if string(rest) != "\x1b[<0;1" {
+		t.Errorf("expected the rest not to change, got %q", rest)
+	}
+
+	// The parser doesn't keep the rest itself.
+	want := []Event{KeyDownEvent{Rune: ';'}, KeyDownEvent{Rune: '1'}, KeyDownEvent{Rune: 'M'}}
+	if events, rest := p.Parse([]byte(";1M")); !reflect.DeepEqual(events, want) || len(rest) != 0 {
+		t.Errorf("expected %v, got %v and %q", want, events, rest)
+	}
+}
+
+func TestNewParserOptions(t *testing.T) {
+	p, err := NewParser(WithFlags(FlagCtrlI), WithProfile(ProfileLinux))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Event{KeyDownEvent{Rune: 'i', Mod: Ctrl}, KeyDownEvent{Sym: KeyF1}}
+	if got := parseChunks(p, "\t\x1b[[A"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if _, err := NewParser(WithConfig(Config{CtrlAt: true, Space: true})); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestDriverParser(t *testing.T) {
+	// The driver parser is configured like the driver.
+	d := newDriver("", FlagCtrlM)
+	events, rest := d.parser.Parse([]byte("\r"))
+	if want := []Event{KeyDownEvent{Rune: 'm', Mod: Ctrl}}; !reflect.DeepEqual(events, want) || len(rest) != 0 {
+		t.Errorf("expected %v, got %v and %q", want, events, rest)
+	}
+}
@@ -1,6 +1,8 @@
 package input
 
-import "strings"
+import (
+	"github.com/rivo/uniseg"
+)
 
 // PasteEvent is an event that is emitted when a terminal receives pasted text
 // using bracketed-paste.
@@ -8,9 +10,23 @@ type PasteEvent string
 
 // String implements fmt.Stringer.
 func (p PasteEvent) String() string {
+	return EventString(p)
+}
+
+// Graphemes returns the pasted text split into grapheme clusters i.e.
+// user-perceived characters such as a letter with combining marks, an emoji
+// ZWJ sequence, or a flag. The driver reports a paste once the paste end
+// marker arrives, so clusters split across reads are always whole.
+func (p PasteEvent) Graphemes() []string {
+	var gs []string
+	state := -1
 	s := string(p)
-	s = strings.ReplaceAll(s, "\n", "\r\n")
-	return s
+	for len(s) > 0 {
+		var g string
+		g, s, _, state = uniseg.FirstGraphemeClusterInString(s, state)
+		gs = append(gs, g)
+	}
+	return gs
 }
 
 // PasteStartEvent is an event that is emitted when a terminal enters
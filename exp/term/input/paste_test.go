@@ -0,0 +1,124 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBracketedPaste(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []Event
+	}{
+		{
+			"paste",
+			"\x1b[200~hello\x1b[201~a",
+			[]Event{PasteStartEvent{}, PasteEvent("hello"), PasteEndEvent{}, KeyDownEvent{Rune: 'a'}},
+		},
+		{
+			"lone paste end",
+			"\x1b[201~",
+			[]Event{UnknownCsiEvent("\x1b[201~")},
+		},
+		{
+			"lone paste end followed by input",
+			"\x1b[201~a\x1b[A",
+			[]Event{UnknownCsiEvent("\x1b[201~"), KeyDownEvent{Rune: 'a'}, KeyDownEvent{Sym: KeyUp}},
+		},
+		{
+			"embedded escape sequences",
+			"\x1b[200~a\x1b[Ab\x1b]11;?\x07\x1b[201~",
+			[]Event{PasteStartEvent{}, PasteEvent("a\x1b[Ab\x1b]11;?\x07"), PasteEndEvent{}},
+		},
+		{
+			"unterminated paste",
+			"\x1b[200~hello\x1b[20",
+			[]Event{PasteStartEvent{}, PasteEvent("hello\x1b[20"), PasteEndEvent{}},
+		},
+		{
+			"lone paste end followed by a paste",
+			"\x1b[201~\x1b[200~hi\x1b[201~",
+			[]Event{UnknownCsiEvent("\x1b[201~"), PasteStartEvent{}, PasteEvent("hi"), PasteEndEvent{}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DecodeString(c.in); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestBracketedPasteReads(t *testing.T) {
+	cases := []struct {
+		name   string
+		chunks []string
+		want   []Event
+	}{
+		{
+			"split content",
+			[]string{"\x1b[200~hel", "lo\nworld", "\x1b[201~"},
+			[]Event{PasteStartEvent{}, PasteEvent("hello\nworld"), PasteEndEvent{}},
+		},
+		{
+			"split end marker",
+			[]string{"\x1b[200~hello\x1b[2", "01~a"},
+			[]Event{PasteStartEvent{}, PasteEvent("hello"), PasteEndEvent{}, KeyDownEvent{Rune: 'a'}},
+		},
+		{
+			"split escape sequence",
+			[]string{"\x1b[200~a\x1b[2", "0A\x1b[201~"},
+			[]Event{PasteStartEvent{}, PasteEvent("a\x1b[20A"), PasteEndEvent{}},
+		},
+		{
+			"unterminated paste at EOF",
+			[]string{"\x1b[200~hello", " world"},
+			[]Event{PasteStartEvent{}, PasteEvent("hello world"), PasteEndEvent{}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := readEvents(t, 0, c.chunks...); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestPasteEventGraphemes(t *testing.T) {
+	cases := []struct {
+		name string
+		in   PasteEvent
+		want []string
+	}{
+		{"empty", "", nil},
+		{"ascii", "ab", []string{"a", "b"}},
+		{"combining marks", "e\u0301a\u0308", []string{"e\u0301", "a\u0308"}},
+		{"emoji zwj sequence", "👩\u200d💻!", []string{"👩\u200d💻", "!"}},
+		{"flags", "🇫🇷🇯🇵", []string{"🇫🇷", "🇯🇵"}},
+		{"crlf", "a\r\nb", []string{"a", "\r\n", "b"}},
+		{"hangul", "각", []string{"각"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.in.Graphemes(); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+
+	// A cluster split across reads is reported whole.
+	events := readEvents(t, 0, "\x1b[200~👩\u200d", "💻e", "\u0301\x1b[201~")
+	want := []Event{PasteStartEvent{}, PasteEvent("👩‍💻é"), PasteEndEvent{}}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("expected %v, got %v", want, events)
+	}
+	if got, want := events[1].(PasteEvent).Graphemes(), []string{"👩‍💻", "é"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
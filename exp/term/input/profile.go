@@ -0,0 +1,119 @@
+package input
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/exp/term/ansi"
+)
+
+// Profile is a terminal key profile. It selects the key sequences registered
+// on top of the VT100/VT200 ones, so that sequences of other terminals don't
+// shadow or collide with the ones the terminal sends. The key sequences of
+// the other profiles aren't decoded as keys either e.g. ProfileXTerm reports
+// the URxvt CSI a as an UnknownCsiEvent and ProfileRxvt reports the XTerm
+// CSI 1 ; 5 A as one.
+type Profile string
+
+// Terminal key profiles.
+const (
+	// ProfileGeneric registers the XTerm and URxvt key sequences. It's used
+	// when the terminal is unknown.
+	ProfileGeneric Profile = "generic"
+
+	// ProfileXTerm registers the XTerm modified key sequences e.g.
+	// CSI 1 ; 5 A. Most modern terminals are compatible with XTerm.
+	ProfileXTerm Profile = "xterm"
+
+	// ProfileRxvt registers the URxvt key sequences e.g. CSI a for
+	// shift+up and CSI 5 ^ for ctrl+pgup.
+	ProfileRxvt Profile = "rxvt-unicode"
+
+	// ProfileLinux registers the Linux console key sequences i.e. CSI [ A
+	// through CSI [ E for F1-F5.
+	ProfileLinux Profile = "linux"
+
+	// ProfileScreen registers the same key sequences as ProfileGeneric. GNU
+	// Screen passes the keys of the outer terminal through.
+	ProfileScreen Profile = "screen"
+
+	// ProfileTmux registers the XTerm key sequences. Tmux translates the
+	// keys of the outer terminal to XTerm ones.
+	ProfileTmux Profile = "tmux"
+)
+
+// DetectProfile returns the key profile of the terminal named term, usually
+// $TERM. It returns ProfileGeneric for unknown terminals.
+func DetectProfile(term string) Profile {
+	switch {
+	case strings.HasPrefix(term, "rxvt"):
+		return ProfileRxvt
+	case term == "linux" || strings.HasPrefix(term, "linux-"):
+		return ProfileLinux
+	case strings.HasPrefix(term, "tmux"):
+		return ProfileTmux
+	case strings.HasPrefix(term, "screen"):
+		return ProfileScreen
+	case strings.HasPrefix(term, "xterm"):
+		return ProfileXTerm
+	}
+	return ProfileGeneric
+}
+
+// profileKeys represents the key sequence sets of a profile.
+type profileKeys struct {
+	xterm, urxvt, linux bool
+}
+
+// profileKeys returns the key sequence sets of the parser profile. The
+// profile is detected from the terminal name when it's not set.
+func (p *Parser) profileKeys() profileKeys {
+	prof := p.profile
+	if prof == "" {
+		prof = DetectProfile(p.term)
+	}
+
+	switch prof {
+	case ProfileXTerm, ProfileTmux:
+		return profileKeys{xterm: true}
+	case ProfileRxvt:
+		return profileKeys{urxvt: true}
+	case ProfileLinux:
+		return profileKeys{linux: true}
+	}
+	return profileKeys{xterm: true, urxvt: true}
+}
+
+// isExcluded reports whether the sequence is a key sequence of another
+// profile. C1 and Alt prefixed forms of the sequence are excluded too.
+func (p *Parser) isExcluded(seq []byte) bool {
+	if len(p.excluded) == 0 || len(seq) < 2 {
+		return false
+	}
+	if seq[0] == ansi.ESC && (seq[1] == ansi.ESC || seq[1] == ansi.CSI || seq[1] == ansi.SS3) {
+		// Alt + <key>
+		seq = seq[1:]
+	}
+
+	s := string(seq)
+	switch seq[0] {
+	case ansi.CSI:
+		s = "\x1b[" + s[1:]
+	case ansi.SS3:
+		s = "\x1bO" + s[1:]
+	}
+	_, ok := p.excluded[s]
+	return ok
+}
+
+// unknownSequence returns the unknown event of a, possibly Alt prefixed, CSI
+// or SS3 sequence.
+func unknownSequence(seq []byte) Event {
+	intro := seq
+	if len(intro) > 1 && intro[0] == ansi.ESC && (intro[1] == ansi.ESC || intro[1] == ansi.CSI || intro[1] == ansi.SS3) {
+		intro = intro[1:]
+	}
+	if intro[0] == ansi.SS3 || len(intro) > 1 && intro[0] == ansi.ESC && intro[1] == 'O' {
+		return UnknownSs3Event(seq)
+	}
+	return UnknownCsiEvent(seq)
+}
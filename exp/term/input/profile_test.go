@@ -0,0 +1,127 @@
+package input
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectProfile(t *testing.T) {
+	cases := []struct {
+		term string
+		want Profile
+	}{
+		{"", ProfileGeneric},
+		{"vt100", ProfileGeneric},
+		{"xterm", ProfileXTerm},
+		{"xterm-256color", ProfileXTerm},
+		{"rxvt-unicode", ProfileRxvt},
+		{"rxvt-unicode-256color", ProfileRxvt},
+		{"linux", ProfileLinux},
+		{"linux-16color", ProfileLinux},
+		{"screen", ProfileScreen},
+		{"screen-256color", ProfileScreen},
+		{"tmux-256color", ProfileTmux},
+	}
+
+	for _, c := range cases {
+		if got := DetectProfile(c.term); got != c.want {
+			t.Errorf("%q: expected %q, got %q", c.term, c.want, got)
+		}
+	}
+}
+
+func TestProfileKeys(t *testing.T) {
+	cases := []struct {
+		term    string
+		present []string
+		absent  []string
+	}{
+		{"", []string{"\x1b[a", "\x1b[5^", "\x1b[1;5A"}, []string{"\x1b[[A"}},
+		{"xterm", []string{"\x1b[1;5A", "\x1b[27;5;9~"}, []string{"\x1b[a", "\x1b[5^", "\x1bOa", "\x1b[[A"}},
+		{"tmux", []string{"\x1b[1;5A"}, []string{"\x1b[a", "\x1b[[A"}},
+		{"screen", []string{"\x1b[a", "\x1b[1;5A"}, []string{"\x1b[[A"}},
+		{"rxvt-unicode", []string{"\x1b[a", "\x1b[5^", "\x1bOa"}, []string{"\x1b[1;5A", "\x1b[[A"}},
+		{"linux", []string{"\x1b[[A", "\x1b[[E"}, []string{"\x1b[a", "\x1b[1;5A"}},
+	}
+
+	for _, c := range cases {
+		d := newDriver(c.term, FlagNoTerminfo)
+		for _, seq := range c.present {
+			if _, ok := d.parser.table[seq]; !ok {
+				t.Errorf("%q: expected %q to be registered", c.term, seq)
+			}
+		}
+		for _, seq := range c.absent {
+			if k, ok := d.parser.table[seq]; ok {
+				t.Errorf("%q: expected %q not to be registered, got %v", c.term, seq, k)
+			}
+		}
+		// The VT100/VT200 keys are always registered.
+		if k := d.parser.table["\x1b[A"]; k != (KeyDownEvent{Sym: KeyUp}) {
+			t.Errorf("%q: expected up, got %v", c.term, k)
+		}
+	}
+}
+
+func TestProfileDecode(t *testing.T) {
+	shiftUp := KeyDownEvent{Sym: KeyUp, Mod: Shift}
+	ctrlUp := KeyDownEvent{Sym: KeyUp, Mod: Ctrl}
+	altShiftUp := KeyDownEvent{Sym: KeyUp, Mod: Shift | Alt}
+	in := "\x1b[a\x1bOa\x1b[1;5A\x1b\x1b[a"
+	cases := []struct {
+		profile Profile
+		want    []Event
+	}{
+		{ProfileGeneric, []Event{shiftUp, ctrlUp, ctrlUp, altShiftUp}},
+		{ProfileXTerm, []Event{UnknownCsiEvent("\x1b[a"), UnknownSs3Event("\x1bOa"), ctrlUp, UnknownCsiEvent("\x1b\x1b[a")}},
+		{ProfileRxvt, []Event{shiftUp, ctrlUp, UnknownCsiEvent("\x1b[1;5A"), altShiftUp}},
+		{ProfileLinux, []Event{UnknownCsiEvent("\x1b[a"), UnknownSs3Event("\x1bOa"), UnknownCsiEvent("\x1b[1;5A"), UnknownCsiEvent("\x1b\x1b[a")}},
+	}
+
+	for _, c := range cases {
+		p, err := NewParser(WithProfile(c.profile), WithFlags(FlagNoTerminfo))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := parseChunks(p, in); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.profile, c.want, got)
+		}
+
+		// The C1 forms are decoded the same.
+		var want []Event
+		for _, e := range c.want[:3] {
+			if u, ok := e.(UnknownCsiEvent); ok {
+				e = UnknownCsiEvent("\x9b" + u[2:])
+			} else if u, ok := e.(UnknownSs3Event); ok {
+				e = UnknownSs3Event("\x8f" + u[2:])
+			}
+			want = append(want, e)
+		}
+		if got := parseChunks(p, "\x9ba\x8fa\x9b1;5A"); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", c.profile, want, got)
+		}
+	}
+}
+
+func TestLinuxConsoleKeys(t *testing.T) {
+	d := newDriver("linux", FlagNoTerminfo)
+	want := []Event{KeyDownEvent{Sym: KeyF1}, KeyDownEvent{Sym: KeyF5}}
+	if got := d.parser.decode([]byte("\x1b[[A\x1b[[E")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestWithProfile(t *testing.T) {
+	d, err := NewDriver(strings.NewReader(""),
+		WithTerminfo("xterm"),
+		WithFlags(FlagNoTerminfo),
+		WithProfile(ProfileRxvt),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if k, want := d.parser.table["\x1b[a"], (KeyDownEvent{Sym: KeyUp, Mod: Shift}); k != want {
+		t.Errorf("expected the profile to override $TERM, got %v", k)
+	}
+}
@@ -0,0 +1,72 @@
+package input
+
+import (
+	"io"
+	"time"
+
+	"github.com/charmbracelet/x/exp/term/ansi"
+)
+
+// Query writes the query to w and reads input until the terminal responds
+// with an event accepted by match, which is returned. Other events read while
+// waiting are discarded.
+//
+// If the terminal doesn't respond within the given timeout, the driver reader
+// is cancelled and ErrTimeout is returned. The driver can't be used to read
+// input after a timeout.
+func (d *Driver) Query(w io.Writer, query string, timeout time.Duration, match func(Event) bool) (Event, error) {
+	if _, err := io.WriteString(w, query); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		e   Event
+		err error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		// Read one event at a time so we don't consume events past the
+		// response.
+		var events [1]Event
+		for {
+			n, err := d.ReadInput(events[:])
+			if err != nil {
+				done <- result{err: err}
+				return
+			}
+			if n > 0 && match(events[0]) {
+				done <- result{e: events[0]}
+				return
+			}
+		}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.e, r.err
+	case <-timer.C:
+		d.Cancel()
+		return nil, ErrTimeout
+	}
+}
+
+// QueryPrimaryDeviceAttributes requests the terminal primary device
+// attributes (DA1) and waits for the response. It returns ErrTimeout if the
+// terminal doesn't respond within the given timeout.
+//
+// Almost all terminals respond to DA1, which makes it handy to detect whether
+// the input is connected to a terminal at all.
+func (d *Driver) QueryPrimaryDeviceAttributes(w io.Writer, timeout time.Duration) (PrimaryDeviceAttributesEvent, error) {
+	e, err := d.Query(w, ansi.RequestPrimaryDeviceAttributes, timeout, func(e Event) bool {
+		_, ok := e.(PrimaryDeviceAttributesEvent)
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.(PrimaryDeviceAttributesEvent), nil
+}
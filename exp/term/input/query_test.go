@@ -0,0 +1,58 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryPrimaryDeviceAttributes(t *testing.T) {
+	d, err := NewDriver(strings.NewReader("a\x1b[?62;4c"))
+	if err != nil {
+		t.Fatalf("unexpected error creating driver: %v", err)
+	}
+
+	var out bytes.Buffer
+	da1, err := d.QueryPrimaryDeviceAttributes(&out, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (PrimaryDeviceAttributesEvent{62, 4}); !reflect.DeepEqual(da1, want) {
+		t.Errorf("expected %v, got %v", want, da1)
+	}
+	if got := out.String(); got != "\x1b[c" {
+		t.Errorf("expected query %q, got %q", "\x1b[c", got)
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	// A reader that never responds.
+	r, w := io.Pipe()
+	defer w.Close() // nolint: errcheck
+
+	d, err := NewDriver(r)
+	if err != nil {
+		t.Fatalf("unexpected error creating driver: %v", err)
+	}
+
+	_, err = d.QueryPrimaryDeviceAttributes(io.Discard, 10*time.Millisecond)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected %v, got %v", ErrTimeout, err)
+	}
+}
+
+func TestQueryEOF(t *testing.T) {
+	d, err := NewDriver(strings.NewReader("abc"))
+	if err != nil {
+		t.Fatalf("unexpected error creating driver: %v", err)
+	}
+
+	_, err = d.QueryPrimaryDeviceAttributes(io.Discard, time.Second)
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("expected %v, got %v", io.EOF, err)
+	}
+}
@@ -0,0 +1,157 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/x/exp/term/ansi"
+	"github.com/muesli/cancelreader"
+)
+
+// DefaultEscTimeout is the default time ReadEvents waits for the rest of a
+// sequence after a trailing ESC before reporting it as the Escape key. It
+// matches the default of common terminal applications.
+const DefaultEscTimeout = 50 * time.Millisecond
+
+// SetEscTimeout sets how long ReadEvents waits for more input after a
+// trailing ESC. If nothing arrives in time, the ESC is reported as the Escape
+// key, otherwise it's decoded with the following bytes e.g. as Alt+key or a
+// key sequence. A zero or negative timeout restores DefaultEscTimeout. It only
+// applies to ReadEvents.
+func (d *Driver) SetEscTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultEscTimeout
+	}
+	d.escTimeout = timeout
+}
+
+// readResult is the result of a single read from the driver reader.
+type readResult struct {
+	b   []byte
+	err error
+}
+
+// ReadEvents reads the next events from the terminal. It blocks until at
+// least one event is available, the context is done, or reading fails.
+//
+// A trailing ESC is ambiguous, it's either the Escape key or the start of a
+// sequence split across reads. ReadEvents holds it until more input arrives,
+// and reports it as the Escape key if nothing arrives within the timeout set
+// by SetEscTimeout or the context is done. Other incomplete sequences are
+// held the same way, they're reported as is when the timeout expires, and
+// kept for the next call when the context is done. The events decoded so far
+// are returned along with the context error. Cancelling the context doesn't
+// cancel the driver reader, the driver can be used again for the next call.
+//
+// The ESC timeout only applies to ReadEvents. ReadInput and PeekInput report
+// a trailing ESC as the Escape key right away.
+//
+// ReadEvents decodes the raw input bytes from a background reader. On
+// Windows, this means the console must be in virtual terminal input mode.
+// Once it's used, ReadInput and PeekInput, which read from the same reader,
+// return ErrMixedRead. Cancel and Close stop the background reader, and
+// ReadEvents returns cancelreader.ErrCanceled afterwards. Once reading fails,
+// every following call returns the read error.
+func (d *Driver) ReadEvents(ctx context.Context) ([]Event, error) {
+	// Report any peeked events first.
+	if len(d.internalEvents) > 0 {
+		events := append([]Event(nil), d.internalEvents...)
+		d.internalEvents = d.internalEvents[:0]
+		return events, nil
+	}
+
+	d.readsOnce.Do(func() {
+		atomic.StoreUint32(&d.reading, 1)
+		d.reads = make(chan readResult, 1)
+		go d.readLoop()
+	})
+
+	if d.readErr != nil {
+		// The background reader stopped, no more bytes are coming.
+		return d.parser.flush(), d.readErr
+	}
+
+	var events []Event
+	for len(events) == 0 {
+		// An incomplete sequence, e.g. a typed ESC [, is held like a
+		// trailing ESC so it doesn't join the next key.
+		var timeout <-chan time.Time
+		if len(d.parser.pending) > 0 && d.parser.paste == nil {
+			timeout = d.after(d.escTimeout)
+		}
+
+		select {
+		case r := <-d.reads:
+			events = append(events, d.decodeHoldEsc(r.b)...)
+			if r.err != nil {
+				d.readErr = r.err
+				if errors.Is(r.err, io.EOF) {
+					// No more bytes are coming, flush what we have.
+					events = append(events, d.parser.flush()...)
+				}
+				return events, r.err
+			}
+		case <-timeout:
+			events = append(events, d.parser.flush()...)
+		case <-d.done:
+			return events, cancelreader.ErrCanceled
+		case <-ctx.Done():
+			if d.isEscPending() {
+				events = append(events, d.parser.flush()...)
+			}
+			return events, ctx.Err()
+		}
+	}
+
+	return events, nil
+}
+
+// readLoop reads from the driver reader until it fails or the driver is
+// cancelled or closed, and sends each read to the reads channel. It's the
+// only reader of the driver reader when ReadEvents is used.
+func (d *Driver) readLoop() {
+	for {
+		var buf [256]byte
+		n, err := d.rd.Read(buf[:])
+		select {
+		case d.reads <- readResult{b: buf[:n], err: err}:
+		case <-d.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// decodeHoldEsc is like decode but holds a trailing ESC, or ESC ESC, as
+// pending instead of decoding it.
+func (d *Driver) decodeHoldEsc(b []byte) []Event {
+	if d.parser.paste != nil || len(b) == 0 || b[len(b)-1] != ansi.ESC {
+		return d.parser.decode(b)
+	}
+
+	buf := append(d.parser.pending, b...)
+	d.parser.pending = nil
+
+	n := len(buf) - 1
+	if n > 0 && buf[n-1] == ansi.ESC {
+		n--
+	}
+	hold := append([]byte(nil), buf[n:]...)
+	events := d.parser.decode(buf[:n])
+	d.parser.pending = append(d.parser.pending, hold...)
+	return events
+}
+
+// isEscPending returns whether the pending bytes are a held ESC.
+func (d *Driver) isEscPending() bool {
+	switch string(d.parser.pending) {
+	case "\x1b", "\x1b\x1b":
+		return d.parser.paste == nil
+	}
+	return false
+}
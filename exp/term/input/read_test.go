@@ -0,0 +1,319 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/muesli/cancelreader"
+)
+
+// newPipeDriver returns a driver that reads from a pipe and the pipe writer.
+func newPipeDriver(t *testing.T, flags int) (*Driver, *io.PipeWriter) {
+	t.Helper()
+
+	r, w := io.Pipe()
+	t.Cleanup(func() { w.Close() }) // nolint: errcheck
+
+	d, err := NewDriver(r, WithFlags(flags))
+	if err != nil {
+		t.Fatalf("unexpected error creating driver: %v", err)
+	}
+	return d, w
+}
+
+// write writes s to w in the background.
+func write(w io.Writer, s string) {
+	go w.Write([]byte(s)) // nolint: errcheck
+}
+
+func TestReadEvents(t *testing.T) {
+	d, w := newPipeDriver(t, 0)
+	ctx := context.Background()
+
+	write(w, "a\x1b[A")
+	events, err := d.ReadEvents(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []Event{KeyDownEvent{Rune: 'a'}, KeyDownEvent{Sym: KeyUp}}; !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+
+	w.Close() // nolint: errcheck
+	for i := 0; i < 2; i++ {
+		if _, err := d.ReadEvents(ctx); !errors.Is(err, io.EOF) {
+			t.Errorf("read %d: expected EOF, got %v", i, err)
+		}
+	}
+}
+
+func TestReadEventsMixedRead(t *testing.T) {
+	d, w := newPipeDriver(t, 0)
+	write(w, "a")
+	if _, err := d.ReadEvents(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := d.ReadInput(make([]Event, 1)); !errors.Is(err, ErrMixedRead) {
+		t.Errorf("expected ErrMixedRead from ReadInput, got %v", err)
+	}
+	if _, err := d.PeekInput(1); !errors.Is(err, ErrMixedRead) {
+		t.Errorf("expected ErrMixedRead from PeekInput, got %v", err)
+	}
+}
+
+func TestReadEventsDriverCancel(t *testing.T) {
+	d, w := newPipeDriver(t, 0)
+	write(w, "a")
+	if _, err := d.ReadEvents(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The background reader is waiting for more input.
+	errc := make(chan error, 1)
+	go func() {
+		_, err := d.ReadEvents(context.Background())
+		errc <- err
+	}()
+	d.Cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, cancelreader.ErrCanceled) {
+			t.Errorf("expected ErrCanceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected ReadEvents to return after Cancel")
+	}
+
+	// The background reader doesn't block on unread input once stopped.
+	done := make(chan struct{})
+	go func() {
+		w.Write([]byte("bc")) // nolint: errcheck
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the stopped reader not to block the writer")
+	}
+}
+
+func TestReadEventsEsc(t *testing.T) {
+	cases := []struct {
+		name   string
+		chunks []string
+		want   []Event
+	}{
+		{"esc alone", []string{"\x1b"}, []Event{KeyDownEvent{Sym: KeyEscape}}},
+		{"alt+esc", []string{"\x1b\x1b"}, []Event{KeyDownEvent{Sym: KeyEscape, Mod: Alt}}},
+		{"key then esc", []string{"a\x1b"}, []Event{KeyDownEvent{Rune: 'a'}, KeyDownEvent{Sym: KeyEscape}}},
+		{"split csi", []string{"\x1b", "[A"}, []Event{KeyDownEvent{Sym: KeyUp}}},
+		{"split alt+a", []string{"a\x1b", "a"}, []Event{KeyDownEvent{Rune: 'a'}, KeyDownEvent{Rune: 'a', Mod: Alt}}},
+		{"split alt+up", []string{"\x1b", "\x1b", "[A"}, []Event{KeyDownEvent{Sym: KeyUp, Mod: Alt}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d, w := newPipeDriver(t, 0)
+			done := make(chan struct{})
+			go func() {
+				// Deliver each chunk by a separate read.
+				for _, s := range c.chunks {
+					w.Write([]byte(s)) // nolint: errcheck
+				}
+				close(done)
+			}()
+
+			var events []Event
+			for len(events) < len(c.want) {
+				evs, err := d.ReadEvents(context.Background())
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				events = append(events, evs...)
+			}
+			<-done
+
+			if !reflect.DeepEqual(events, c.want) {
+				t.Errorf("expected %v, got %v", c.want, events)
+			}
+		})
+	}
+}
+
+func TestReadEventsCancel(t *testing.T) {
+	d, w := newPipeDriver(t, 0)
+
+	// Nothing to read.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	events, err := d.ReadEvents(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || len(events) != 0 {
+		t.Fatalf("expected no events and a deadline error, got %v and %v", events, err)
+	}
+
+	// A half-parsed sequence is kept for the next call.
+	w.Write([]byte("a\x1b[<0;10;10")) // nolint: errcheck
+	ctx, cancel = context.WithCancel(context.Background())
+	events, err = d.ReadEvents(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []Event{KeyDownEvent{Rune: 'a'}}; !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+	cancel()
+	if events, err := d.ReadEvents(ctx); !errors.Is(err, context.Canceled) || len(events) != 0 {
+		t.Fatalf("expected no events and a cancel error, got %v and %v", events, err)
+	}
+
+	write(w, "M")
+	events, err = d.ReadEvents(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []Event{MouseDownEvent{X: 9, Y: 9, Button: MouseButtonLeft}}; !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v", want, events)
+	}
+
+	// A held ESC is reported when the context is done.
+	w.Write([]byte("\x1b")) // nolint: errcheck
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	events, err = d.ReadEvents(ctx)
+	if len(events) == 0 {
+		// The read might not have been received before the context was done.
+		events, err = d.ReadEvents(context.Background())
+	}
+	if want := []Event{KeyDownEvent{Sym: KeyEscape}}; !reflect.DeepEqual(events, want) {
+		t.Errorf("expected %v, got %v (%v)", want, events, err)
+	}
+}
+
+// fakeTimer replaces the driver timers with ones fired by the test.
+type fakeTimer struct {
+	started chan time.Duration
+	fire    chan time.Time
+}
+
+func newFakeTimer(d *Driver) *fakeTimer {
+	ft := &fakeTimer{started: make(chan time.Duration, 1), fire: make(chan time.Time)}
+	d.after = func(timeout time.Duration) <-chan time.Time {
+		ft.started <- timeout
+		return ft.fire
+	}
+	return ft
+}
+
+func TestReadEventsEscTimeout(t *testing.T) {
+	readAsync := func(d *Driver) <-chan []Event {
+		ch := make(chan []Event, 1)
+		go func() {
+			events, err := d.ReadEvents(context.Background())
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			ch <- events
+		}()
+		return ch
+	}
+
+	t.Run("esc alone", func(t *testing.T) {
+		d, w := newPipeDriver(t, 0)
+		d.SetEscTimeout(100 * time.Millisecond)
+		ft := newFakeTimer(d)
+
+		ch := readAsync(d)
+		w.Write([]byte("\x1b")) // nolint: errcheck
+		if timeout := <-ft.started; timeout != 100*time.Millisecond {
+			t.Errorf("expected a 100ms timeout, got %v", timeout)
+		}
+		ft.fire <- time.Time{}
+		if events, want := <-ch, []Event{KeyDownEvent{Sym: KeyEscape}}; !reflect.DeepEqual(events, want) {
+			t.Errorf("expected %v, got %v", want, events)
+		}
+	})
+
+	t.Run("esc then [", func(t *testing.T) {
+		d, w := newPipeDriver(t, 0)
+		ft := newFakeTimer(d)
+
+		ch := readAsync(d)
+		w.Write([]byte("\x1b")) // nolint: errcheck
+		if timeout := <-ft.started; timeout != DefaultEscTimeout {
+			t.Errorf("expected the default timeout, got %v", timeout)
+		}
+		w.Write([]byte("[A")) // nolint: errcheck
+		if events, want := <-ch, []Event{KeyDownEvent{Sym: KeyUp}}; !reflect.DeepEqual(events, want) {
+			t.Errorf("expected %v, got %v", want, events)
+		}
+	})
+
+	t.Run("esc then a", func(t *testing.T) {
+		d, w := newPipeDriver(t, 0)
+		ft := newFakeTimer(d)
+
+		ch := readAsync(d)
+		w.Write([]byte("\x1b")) // nolint: errcheck
+		<-ft.started
+		w.Write([]byte("a")) // nolint: errcheck
+		if events, want := <-ch, []Event{KeyDownEvent{Rune: 'a', Mod: Alt}}; !reflect.DeepEqual(events, want) {
+			t.Errorf("expected %v, got %v", want, events)
+		}
+	})
+
+	t.Run("alt+a", func(t *testing.T) {
+		d, w := newPipeDriver(t, 0)
+		ft := newFakeTimer(d)
+
+		ch := readAsync(d)
+		w.Write([]byte("\x1ba")) // nolint: errcheck
+		if events, want := <-ch, []Event{KeyDownEvent{Rune: 'a', Mod: Alt}}; !reflect.DeepEqual(events, want) {
+			t.Errorf("expected %v, got %v", want, events)
+		}
+		select {
+		case <-ft.started:
+			t.Errorf("expected no timeout")
+		default:
+		}
+	})
+
+	t.Run("split sequence", func(t *testing.T) {
+		d, w := newPipeDriver(t, 0)
+		ft := newFakeTimer(d)
+
+		ch := readAsync(d)
+		w.Write([]byte("\x1b[1;5")) // nolint: errcheck
+		<-ft.started
+		w.Write([]byte("A")) // nolint: errcheck
+		if events, want := <-ch, []Event{KeyDownEvent{Sym: KeyUp, Mod: Ctrl}}; !reflect.DeepEqual(events, want) {
+			t.Errorf("expected %v, got %v", want, events)
+		}
+	})
+
+	t.Run("incomplete sequence", func(t *testing.T) {
+		d, w := newPipeDriver(t, 0)
+		ft := newFakeTimer(d)
+
+		ch := readAsync(d)
+		w.Write([]byte("\x1b[")) // nolint: errcheck
+		<-ft.started
+		ft.fire <- time.Time{}
+		if events, want := <-ch, []Event{UnknownEvent("\x1b[")}; !reflect.DeepEqual(events, want) {
+			t.Errorf("expected %v, got %v", want, events)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		d, _ := newPipeDriver(t, 0)
+		d.SetEscTimeout(time.Second)
+		d.SetEscTimeout(0)
+		if d.escTimeout != DefaultEscTimeout {
+			t.Errorf("expected the default timeout, got %v", d.escTimeout)
+		}
+	})
+}
@@ -1,7 +1,7 @@
 package input
 
 import (
-	"fmt"
+	"github.com/charmbracelet/x/exp/term/ansi"
 )
 
 // UnknownCsiEvent represents an unknown CSI sequence event.
@@ -9,7 +9,73 @@ type UnknownCsiEvent string
 
 // String implements fmt.Stringer.
 func (e UnknownCsiEvent) String() string {
-	return fmt.Sprintf("%q", string(e))
+	return EventString(e)
+}
+
+// Marker returns the private marker of the sequence i.e. one of '<', '=',
+// '>', or '?'. It returns zero if the sequence doesn't have a marker.
+func (e UnknownCsiEvent) Marker() byte {
+	marker, _, _, _ := e.split()
+	return marker
+}
+
+// Params returns the parsed parameters of the sequence. Each parameter can
+// have sub-parameters separated by ':'.
+func (e UnknownCsiEvent) Params() [][]uint {
+	_, params, _, _ := e.split()
+	if len(params) == 0 {
+		return nil
+	}
+	return ansi.Params(params)
+}
+
+// Intermediates returns the intermediate bytes of the sequence.
+func (e UnknownCsiEvent) Intermediates() []byte {
+	_, _, inters, _ := e.split()
+	return inters
+}
+
+// Final returns the final byte of the sequence. It returns zero if the
+// sequence is incomplete.
+func (e UnknownCsiEvent) Final() byte {
+	_, _, _, final := e.split()
+	return final
+}
+
+// split returns the different parts of the sequence.
+func (e UnknownCsiEvent) split() (marker byte, params, inters []byte, final byte) {
+	p := []byte(e)
+	switch {
+	case len(p) > 0 && p[0] == ansi.CSI:
+		p = p[1:]
+	case len(p) > 1 && p[0] == ansi.ESC && p[1] == '[':
+		p = p[2:]
+	default:
+		return
+	}
+
+	// Scan parameter bytes in the range 0x30-0x3F
+	var i int
+	for ; i < len(p) && p[i] >= 0x30 && p[i] <= 0x3F; i++ {
+	}
+	params = p[:i]
+	if len(params) > 0 && params[0] >= '<' && params[0] <= '?' {
+		marker = params[0]
+		params = params[1:]
+	}
+
+	// Scan intermediate bytes in the range 0x20-0x2F
+	start := i
+	for ; i < len(p) && p[i] >= 0x20 && p[i] <= 0x2F; i++ {
+	}
+	inters = p[start:i]
+
+	// Final byte in the range 0x40-0x7E
+	if i < len(p) && p[i] >= 0x40 && p[i] <= 0x7E {
+		final = p[i]
+	}
+
+	return
 }
 
 // UnknownOscEvent represents an unknown OSC sequence event.
@@ -17,7 +83,28 @@ type UnknownOscEvent string
 
 // String implements fmt.Stringer.
 func (e UnknownOscEvent) String() string {
-	return fmt.Sprintf("%q", string(e))
+	return EventString(e)
+}
+
+// Code returns the command number of the sequence i.e. Ps in OSC Ps ; Pt ST.
+// It returns -1 if the sequence doesn't start with a command number.
+func (e UnknownOscEvent) Code() int {
+	code, _, ok := splitOsc(oscData(string(e)))
+	if !ok {
+		return -1
+	}
+	return code
+}
+
+// Data returns the payload of the sequence after the command number i.e. Pt
+// in OSC Ps ; Pt ST. It returns nil if the sequence doesn't start with a
+// command number.
+func (e UnknownOscEvent) Data() []byte {
+	_, payload, ok := splitOsc(oscData(string(e)))
+	if !ok {
+		return nil
+	}
+	return []byte(payload)
 }
 
 // UnknownDcsEvent represents an unknown DCS sequence event.
@@ -25,7 +112,7 @@ type UnknownDcsEvent string
 
 // String implements fmt.Stringer.
 func (e UnknownDcsEvent) String() string {
-	return fmt.Sprintf("%q", string(e))
+	return EventString(e)
 }
 
 // UnknownApcEvent represents an unknown APC sequence event.
@@ -33,7 +120,7 @@ type UnknownApcEvent string
 
 // String implements fmt.Stringer.
 func (e UnknownApcEvent) String() string {
-	return fmt.Sprintf("%q", string(e))
+	return EventString(e)
 }
 
 // UnknownSs3Event represents an unknown SS3 sequence event.
@@ -41,5 +128,5 @@ type UnknownSs3Event string
 
 // String implements fmt.Stringer.
 func (e UnknownSs3Event) String() string {
-	return fmt.Sprintf("%q", string(e))
+	return EventString(e)
 }
@@ -0,0 +1,103 @@
+package input
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownCsiEvent(t *testing.T) {
+	cases := []struct {
+		seq    string
+		marker byte
+		params [][]uint
+		inters []byte
+		final  byte
+	}{
+		// Unknown final byte
+		{"\x1b[1;2z", 0, [][]uint{{1}, {2}}, nil, 'z'},
+		// Private marker with sub-parameters
+		{"\x1b[>4:1;2;3w", '>', [][]uint{{4, 1}, {2}, {3}}, nil, 'w'},
+		// Intermediate bytes
+		{"\x1b[?5 %j", '?', [][]uint{{5}}, []byte(" %"), 'j'},
+		// 8-bit CSI
+		{"\x9b12;34x", 0, [][]uint{{12}, {34}}, nil, 'x'},
+		// No parameters
+		{"\x1b[o", 0, nil, nil, 'o'},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		csi, ok := e.(UnknownCsiEvent)
+		if !ok {
+			t.Errorf("%q: expected UnknownCsiEvent, got %T", c.seq, e)
+			continue
+		}
+		if csi.Marker() != c.marker {
+			t.Errorf("%q: expected marker %q, got %q", c.seq, c.marker, csi.Marker())
+		}
+		if !reflect.DeepEqual(csi.Params(), c.params) {
+			t.Errorf("%q: expected params %v, got %v", c.seq, c.params, csi.Params())
+		}
+		if string(csi.Intermediates()) != string(c.inters) {
+			t.Errorf("%q: expected intermediates %q, got %q", c.seq, c.inters, csi.Intermediates())
+		}
+		if csi.Final() != c.final {
+			t.Errorf("%q: expected final %q, got %q", c.seq, c.final, csi.Final())
+		}
+	}
+
+	// Unknown sequences don't corrupt the stream.
+	want := []Event{UnknownCsiEvent("\x1b[1;2z"), KeyDownEvent{Rune: 'a'}, KeyDownEvent{Sym: KeyUp}}
+	if got := DecodeString("\x1b[1;2za\x1b[A"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestUnknownEventString(t *testing.T) {
+	cases := []struct {
+		e    fmt.Stringer
+		want string
+	}{
+		{UnknownEvent("\x1b[?1"), `UnknownEvent("\x1b[?1")`},
+		{UnknownCsiEvent("\x1b[?1h"), `UnknownCsiEvent("\x1b[?1h")`},
+		{UnknownCsiEvent("\x9b1;2z"), `UnknownCsiEvent("\x9b1;2z")`},
+		{UnknownOscEvent("\x1b]999;data\a"), `UnknownOscEvent("\x1b]999;data\a")`},
+		{UnknownOscEvent("\x1b]999;data\x1b\\"), `UnknownOscEvent("\x1b]999;data\x1b\\")`},
+		{UnknownDcsEvent("\x1bP1$r\x1b\\"), `UnknownDcsEvent("\x1bP1$r\x1b\\")`},
+		{UnknownApcEvent("\x1b_Gi=1\x1b\\"), `UnknownApcEvent("\x1b_Gi=1\x1b\\")`},
+		{UnknownSs3Event("\x1bOz"), `UnknownSs3Event("\x1bOz")`},
+	}
+
+	for _, c := range cases {
+		if got := c.e.String(); got != c.want {
+			t.Errorf("%T: expected %s, got %s", c.e, c.want, got)
+		}
+	}
+}
+
+func TestUnknownEventsPreserveBytes(t *testing.T) {
+	for _, seq := range []string{"\x1b[1;2z", "\x9b1;2z", "\x1b]999;\xff\x07", "\x1bOz"} {
+		_, e := ParseSequence([]byte(seq))
+		var got string
+		switch e := e.(type) {
+		case UnknownEvent:
+			got = string(e)
+		case UnknownCsiEvent:
+			got = string(e)
+		case UnknownOscEvent:
+			got = string(e)
+		case UnknownSs3Event:
+			got = string(e)
+		default:
+			t.Errorf("%q: expected an unknown event, got %T", seq, e)
+			continue
+		}
+		if got != seq {
+			t.Errorf("%q: expected the original bytes, got %q", seq, got)
+		}
+	}
+}
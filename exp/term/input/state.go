@@ -0,0 +1,86 @@
+package input
+
+import (
+	"image"
+
+	"github.com/erikgeiser/coninput"
+)
+
+// ParserState is a snapshot of the parser state. It's opaque and can only be
+// used to restore a parser to a previous state using RestoreState.
+type ParserState struct {
+	pending         []byte
+	paste           []byte
+	surrogates      surrogates
+	prevMouse       *image.Point
+	clicks          clickState
+	modifyOtherKeys ModifyOtherKeysEvent
+}
+
+// SnapshotState returns a snapshot of the parser state. This includes
+// incomplete sequences, the bracketed paste buffer, unpaired UTF-16
+// surrogates, the mouse tracking state, and the modifyOtherKeys mode. The
+// parser flags and the key table are not part of the state.
+func (p *Parser) SnapshotState() ParserState {
+	s := ParserState{
+		pending:         append([]byte(nil), p.pending...),
+		surrogates:      p.surrogates,
+		clicks:          p.clicks,
+		modifyOtherKeys: p.modifyOtherKeys,
+	}
+	if p.paste != nil {
+		s.paste = append([]byte{}, p.paste...)
+	}
+	if p.prevMouse != nil {
+		pt := *p.prevMouse
+		s.prevMouse = &pt
+	}
+	return s
+}
+
+// RestoreState restores the parser state from a snapshot taken with
+// SnapshotState. The snapshot can be restored multiple times, and to other
+// parsers.
+func (p *Parser) RestoreState(s ParserState) {
+	p.pending = append([]byte(nil), s.pending...)
+	p.paste = nil
+	if s.paste != nil {
+		p.paste = append([]byte{}, s.paste...)
+	}
+	p.surrogates = s.surrogates
+	p.clicks = s.clicks
+	p.modifyOtherKeys = s.modifyOtherKeys
+	p.prevMouse = nil
+	if s.prevMouse != nil {
+		pt := *s.prevMouse
+		p.prevMouse = &pt
+	}
+}
+
+// DriverState is a snapshot of the driver parsing state. It's opaque and can
+// only be used to restore a driver to a previous state using RestoreState.
+type DriverState struct {
+	parser         ParserState
+	internalEvents []Event
+	prevMouseState coninput.ButtonState
+}
+
+// SnapshotState returns a snapshot of the driver parsing state. This
+// includes the parser state, see Parser.SnapshotState, peeked events, and
+// the Windows Console mouse button state. The driver flags and the
+// underlying reader are not part of the state.
+func (d *Driver) SnapshotState() DriverState {
+	return DriverState{
+		parser:         d.parser.SnapshotState(),
+		internalEvents: append([]Event(nil), d.internalEvents...),
+		prevMouseState: d.prevMouseState,
+	}
+}
+
+// RestoreState restores the driver parsing state from a snapshot taken with
+// SnapshotState. The snapshot can be restored multiple times.
+func (d *Driver) RestoreState(s DriverState) {
+	d.parser.RestoreState(s.parser)
+	d.internalEvents = append(d.internalEvents[:0:0], s.internalEvents...)
+	d.prevMouseState = s.prevMouseState
+}
@@ -0,0 +1,80 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRestoreState(t *testing.T) {
+	d := newDriver("", FlagMouseDelta)
+
+	// Leave the driver in the middle of a bracketed paste with a known mouse
+	// position.
+	d.parser.decode([]byte("\x1b[<35;5;5M\x1b[200~abc"))
+	s := d.SnapshotState()
+
+	want := []Event{PasteEvent("abcdef"), PasteEndEvent{}, MouseMoveEvent{X: 5, Y: 5, DX: 1, DY: 1}}
+	if got := d.parser.decode([]byte("def\x1b[201~\x1b[<35;6;6M")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Restoring the snapshot puts the driver back in the middle of the paste.
+	d.RestoreState(s)
+	want = []Event{PasteEvent("abcxyz"), PasteEndEvent{}, MouseMoveEvent{X: 9, Y: 9, DX: 5, DY: 5}}
+	if got := d.parser.decode([]byte("xyz\x1b[201~\x1b[<35;10;10M")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// The same snapshot can be restored to a different driver.
+	clone := newDriver("", FlagMouseDelta)
+	clone.RestoreState(s)
+	want = []Event{PasteEvent("abc"), PasteEndEvent{}}
+	if got := clone.parser.decode([]byte("\x1b[201~")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// And the snapshot isn't affected by the drivers that used it.
+	d.RestoreState(s)
+	want = []Event{PasteEvent("abc!"), PasteEndEvent{}}
+	if got := d.parser.decode([]byte("!\x1b[201~")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSnapshotRestoreParserState(t *testing.T) {
+	p := newParser("", 0)
+
+	// Leave the parser in the middle of a CSI sequence.
+	if got := p.decode([]byte("\x1b[1;")); len(got) != 0 {
+		t.Fatalf("expected no events, got %v", got)
+	}
+	s := p.SnapshotState()
+
+	// Feed unrelated input to the parser and to a clone.
+	clone := newParser("", 0)
+	clone.RestoreState(s)
+	p.decode([]byte("x"))
+	clone.decode([]byte("y"))
+
+	// The restored states complete the sequence.
+	want := []Event{KeyDownEvent{Sym: KeyUp, Mod: Ctrl}}
+	for _, p := range []*Parser{p, clone} {
+		p.RestoreState(s)
+		if got := p.decode([]byte("5A")); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+
+	// Unpaired surrogates and the modifyOtherKeys mode are part of the state.
+	p = newParser("", 0)
+	p.decode([]byte("\x1b[>4;2m\x1b[0;0;55357;1;0;1_"))
+	s = p.SnapshotState()
+	p.decode([]byte("\x1b[>4;1m\x1b[0;0;97;1;0;1_"))
+
+	clone = newParser("", 0)
+	clone.RestoreState(s)
+	want = []Event{KeyDownEvent{Rune: '😀'}, KeyDownEvent{Rune: 'A'}}
+	if got := clone.decode([]byte("\x1b[0;0;56832;1;0;1_\x1b[27;2;97~")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -1,18 +1,113 @@
 package input
 
 import (
+	"bytes"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/x/exp/term/ansi"
 )
 
-func (d *Driver) registerKeys(flags int) {
-	nul := KeyDownEvent{Sym: KeySpace, Mod: Ctrl} // ctrl+@ or ctrl+space
-	if flags&FlagSpace != 0 {
-		nul = KeyDownEvent{Rune: ' ', Mod: Ctrl}
+// SetC0Handler sets the key event reported for the C0 control character c
+// i.e. 0x00-0x1f, overriding the default and the one chosen by the parser
+// flags. The override is also reported with the Alt modifier when the
+// character is prefixed with ESC. Use it for terminals or devices that remap
+// control keys. It does nothing if c isn't a C0 control character.
+func (p *Parser) SetC0Handler(c byte, k KeyDownEvent) {
+	if c > ansi.US {
+		return
+	}
+	if p.c0 == nil {
+		p.c0 = make(map[byte]KeyDownEvent)
+	}
+	p.c0[c] = k
+	p.registerKeys(p.flags)
+}
+
+// RegisterKey registers the key event reported for the key sequence seq,
+// overriding the built-in one if any. Use it for terminals that report keys
+// the parser doesn't know about. Custom key sequences take precedence over
+// the built-in and Terminfo ones, and a later registration of the same
+// sequence replaces an earlier one. The sequence is matched as is, it isn't
+// registered with the ESC prefix for the Alt modifier.
+//
+// It returns ErrInvalidSequence if seq is empty or doesn't start with a
+// control character i.e. a C0 control, DEL, or a C1 control byte. Text isn't
+// a key sequence.
+func (p *Parser) RegisterKey(seq string, k KeyDownEvent) error {
+	return p.RegisterKeys(map[string]KeyDownEvent{seq: k})
+}
+
+// RegisterKeys registers multiple key sequences at once, see RegisterKey.
+// None of the sequences are registered if any of them is invalid.
+func (p *Parser) RegisterKeys(keys map[string]KeyDownEvent) error {
+	for seq := range keys {
+		if !isKeySequence(seq) {
+			return fmt.Errorf("%w: %q", ErrInvalidSequence, seq)
+		}
+	}
+
+	if p.keys == nil {
+		p.keys = make(map[string]KeyDownEvent, len(keys))
+	}
+	for seq, k := range keys {
+		p.keys[seq] = k
+		delete(p.removed, seq)
+	}
+	p.registerKeys(p.flags)
+	return nil
+}
+
+// UnregisterKey removes the key sequence seq, whether it was registered with
+// RegisterKey or is a built-in one. The sequence is then parsed like any
+// other input, e.g. as an unknown sequence.
+func (p *Parser) UnregisterKey(seq string) {
+	delete(p.keys, seq)
+	if p.removed == nil {
+		p.removed = make(map[string]struct{})
+	}
+	p.removed[seq] = struct{}{}
+	p.registerKeys(p.flags)
+}
+
+// Lookup returns the key event registered for the key sequence seq. It only
+// looks up the key table, sequences decoded by the parser e.g. Kitty keys
+// aren't reported.
+func (p *Parser) Lookup(seq string) (KeyDownEvent, bool) {
+	k, ok := p.table[seq]
+	return k, ok
+}
+
+// Table returns a copy of the key table of the parser i.e. the key sequences
+// and the key events they're reported as given the parser flags, profile,
+// Terminfo, and custom keys.
+func (p *Parser) Table() map[string]KeyDownEvent {
+	t := make(map[string]KeyDownEvent, len(p.table))
+	for seq, k := range p.table {
+		t[seq] = k
+	}
+	return t
+}
+
+// isKeySequence reports whether seq can be registered as a key sequence.
+func isKeySequence(seq string) bool {
+	if len(seq) == 0 {
+		return false
 	}
-	if flags&FlagCtrlAt != 0 {
+	c := seq[0]
+	return c <= ansi.US || c == ansi.DEL || (c >= 0x80 && c <= 0x9f)
+}
+
+func (p *Parser) registerKeys(flags int) {
+	// NUL is reported as ctrl+@ when FlagCtrlAt is set, regardless of
+	// FlagSpace, otherwise as ctrl+space.
+	nul := KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl} // ctrl+@ or ctrl+space
+	switch {
+	case flags&FlagCtrlAt != 0:
 		nul = KeyDownEvent{Rune: '@', Mod: Ctrl}
+	case flags&FlagSpace != 0:
+		nul = KeyDownEvent{Rune: ' ', Mod: Ctrl}
 	}
 
 	tab := KeyDownEvent{Sym: KeyTab} // ctrl+i or tab
@@ -58,7 +153,7 @@ func (d *Driver) registerKeys(flags int) {
 	//
 	// XXX: These keys may be overwritten by other options like XTerm or
 	// Terminfo.
-	d.table = map[string]KeyDownEvent{
+	p.table = map[string]KeyDownEvent{
 		// C0 control characters
 		string(byte(ansi.NUL)): nul,
 		string(byte(ansi.SOH)): {Rune: 'a', Mod: Ctrl},
@@ -100,6 +195,7 @@ func (d *Driver) registerKeys(flags int) {
 		// Special keys
 
 		"\x1b[Z": {Sym: KeyTab, Mod: Shift},
+		"\x1bOZ": {Sym: KeyTab, Mod: Shift}, // some terminals use SS3
 
 		"\x1b[1~": find,
 		"\x1b[2~": {Sym: KeyInsert},
@@ -137,6 +233,9 @@ func (d *Driver) registerKeys(flags int) {
 		"\x1bOS": {Sym: KeyF4},
 
 		// Keypad Application Mode (DECKPAM)
+		//
+		// Not every keypad has an equals key, and many terminals never send
+		// SS3 X even when they have one. Apps shouldn't depend on KeyKpEqual.
 
 		"\x1bOM": {Sym: KeyKpEnter},
 		"\x1bOX": {Sym: KeyKpEqual},
@@ -181,6 +280,20 @@ func (d *Driver) registerKeys(flags int) {
 		"\x1b[34~": {Sym: KeyF20},
 	}
 
+	// The key sequences registered on top of the VT100/VT200 ones depend on
+	// the terminal profile.
+	keys := p.profileKeys()
+
+	// The sequences of the key sets the profile doesn't register are
+	// recorded so the parser doesn't decode them as keys either.
+	excluded := map[string]KeyDownEvent{}
+	profileTable := func(registered bool) map[string]KeyDownEvent {
+		if registered {
+			return p.table
+		}
+		return excluded
+	}
+
 	// XTerm modifiers
 	// These are offset by 1 to be compatible with our Mod type.
 	// See https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-PC-Style-Function-Keys
@@ -209,7 +322,7 @@ func (d *Driver) registerKeys(flags int) {
 		"E": {Sym: KeyBegin}, "F": {Sym: KeyEnd},
 		"H": {Sym: KeyHome}, "P": {Sym: KeyF1},
 		"Q": {Sym: KeyF2}, "R": {Sym: KeyF3},
-		"S": {Sym: KeyF4},
+		"S": {Sym: KeyF4}, "Z": {Sym: KeyTab, Mod: Shift},
 	}
 
 	// SS3 keypad function keys
@@ -248,53 +361,72 @@ func (d *Driver) registerKeys(flags int) {
 	}
 
 	if flags&FlagNoXTerm == 0 {
+		xterm := profileTable(keys.xterm)
 		for _, m := range modifiers {
-			// XTerm modifier offset +1
-			xtermMod := strconv.Itoa(int(m) + 1)
+			xtermMod := strconv.FormatUint(uint64(encodeXTermModifier(m)), 10)
 
-			//  CSI 1 ; <modifier> <func>
+			//  CSI 1 ; <modifier> <func> and SS3 <modifier> <func>
+			// Terminals that only implement the application (SS3) form of
+			// the cursor and Home/End keys send the modifier right after
+			// the SS3 introducer.
 			for k, v := range csiFuncKeys {
 				// Functions always have a leading 1 param
 				seq := "\x1b[1;" + xtermMod + k
 				key := v
-				key.Mod = m
-				d.table[seq] = key
+				key.Mod |= m // shift+tab already has the Shift modifier
+				xterm[seq] = key
+				xterm["\x1bO"+xtermMod+k] = key
+			}
+			// CSI <modifier> <func>
+			// Some terminals, e.g. older Konsole and VTE versions, send
+			// modified F1-F4 without the leading 1 param. These don't collide
+			// with cursor position reports which always have two params.
+			for _, k := range []string{"P", "Q", "R", "S"} {
+				key := csiFuncKeys[k]
+				key.Mod |= m
+				xterm["\x1b["+xtermMod+k] = key
 			}
 			// SS3 <modifier> <func>
 			for k, v := range ss3FuncKeys {
 				seq := "\x1bO" + xtermMod + k
 				key := v
-				key.Mod = m
-				d.table[seq] = key
+				key.Mod |= m
+				xterm[seq] = key
 			}
 			//  CSI <number> ; <modifier> ~
 			for k, v := range csiTildeKeys {
 				seq := "\x1b[" + k + ";" + xtermMod + "~"
 				key := v
-				key.Mod = m
-				d.table[seq] = key
+				key.Mod |= m
+				xterm[seq] = key
 			}
 			// CSI 27 ; <modifier> ; <code> ~
 			for k, v := range modifyOtherKeys {
 				code := strconv.Itoa(k)
 				seq := "\x1b[27;" + xtermMod + ";" + code + "~"
 				key := v
-				key.Mod = m
-				d.table[seq] = key
+				if k == ansi.SP {
+					// Space has both a key symbol and a rune, use the same
+					// representation as the unmodified key.
+					key = sp
+				}
+				key.Mod |= m
+				xterm[seq] = key
 			}
 		}
 	}
 
 	// URxvt keys
 	// See https://manpages.ubuntu.com/manpages/trusty/man7/urxvt.7.html#key%20codes
-	d.table["\x1b[a"] = KeyDownEvent{Sym: KeyUp, Mod: Shift}
-	d.table["\x1b[b"] = KeyDownEvent{Sym: KeyDown, Mod: Shift}
-	d.table["\x1b[c"] = KeyDownEvent{Sym: KeyRight, Mod: Shift}
-	d.table["\x1b[d"] = KeyDownEvent{Sym: KeyLeft, Mod: Shift}
-	d.table["\x1bOa"] = KeyDownEvent{Sym: KeyUp, Mod: Ctrl}
-	d.table["\x1bOb"] = KeyDownEvent{Sym: KeyDown, Mod: Ctrl}
-	d.table["\x1bOc"] = KeyDownEvent{Sym: KeyRight, Mod: Ctrl}
-	d.table["\x1bOd"] = KeyDownEvent{Sym: KeyLeft, Mod: Ctrl}
+	urxvt := profileTable(keys.urxvt)
+	urxvt["\x1b[a"] = KeyDownEvent{Sym: KeyUp, Mod: Shift}
+	urxvt["\x1b[b"] = KeyDownEvent{Sym: KeyDown, Mod: Shift}
+	urxvt["\x1b[c"] = KeyDownEvent{Sym: KeyRight, Mod: Shift}
+	urxvt["\x1b[d"] = KeyDownEvent{Sym: KeyLeft, Mod: Shift}
+	urxvt["\x1bOa"] = KeyDownEvent{Sym: KeyUp, Mod: Ctrl}
+	urxvt["\x1bOb"] = KeyDownEvent{Sym: KeyDown, Mod: Ctrl}
+	urxvt["\x1bOc"] = KeyDownEvent{Sym: KeyRight, Mod: Ctrl}
+	urxvt["\x1bOd"] = KeyDownEvent{Sym: KeyLeft, Mod: Ctrl}
 	// TODO: invistigate if shift-ctrl arrow keys collide with DECCKM keys i.e.
 	// "\x1bOA", "\x1bOB", "\x1bOC", "\x1bOD"
 
@@ -304,13 +436,13 @@ func (d *Driver) registerKeys(flags int) {
 		// Normal (no modifier) already defined part of VT100/VT200
 		// Shift modifier
 		key.Mod = Shift
-		d.table["\x1b["+k+"$"] = key
+		urxvt["\x1b["+k+"$"] = key
 		// Ctrl modifier
 		key.Mod = Ctrl
-		d.table["\x1b["+k+"^"] = key
+		urxvt["\x1b["+k+"^"] = key
 		// Shift-Ctrl modifier
 		key.Mod = Shift | Ctrl
-		d.table["\x1b["+k+"@"] = key
+		urxvt["\x1b["+k+"@"] = key
 	}
 
 	// URxvt F keys
@@ -322,55 +454,205 @@ func (d *Driver) registerKeys(flags int) {
 	// different escapes like XTerm, or switch to a better terminal ¯\_(ツ)_/¯
 	//
 	// See https://manpages.ubuntu.com/manpages/trusty/man7/urxvt.7.html#key%20codes
-	d.table["\x1b[23$"] = KeyDownEvent{Sym: KeyF11, Mod: Shift}
-	d.table["\x1b[24$"] = KeyDownEvent{Sym: KeyF12, Mod: Shift}
-	d.table["\x1b[25$"] = KeyDownEvent{Sym: KeyF13, Mod: Shift}
-	d.table["\x1b[26$"] = KeyDownEvent{Sym: KeyF14, Mod: Shift}
-	d.table["\x1b[28$"] = KeyDownEvent{Sym: KeyF15, Mod: Shift}
-	d.table["\x1b[29$"] = KeyDownEvent{Sym: KeyF16, Mod: Shift}
-	d.table["\x1b[31$"] = KeyDownEvent{Sym: KeyF17, Mod: Shift}
-	d.table["\x1b[32$"] = KeyDownEvent{Sym: KeyF18, Mod: Shift}
-	d.table["\x1b[33$"] = KeyDownEvent{Sym: KeyF19, Mod: Shift}
-	d.table["\x1b[34$"] = KeyDownEvent{Sym: KeyF20, Mod: Shift}
-	d.table["\x1b[11^"] = KeyDownEvent{Sym: KeyF1, Mod: Ctrl}
-	d.table["\x1b[12^"] = KeyDownEvent{Sym: KeyF2, Mod: Ctrl}
-	d.table["\x1b[13^"] = KeyDownEvent{Sym: KeyF3, Mod: Ctrl}
-	d.table["\x1b[14^"] = KeyDownEvent{Sym: KeyF4, Mod: Ctrl}
-	d.table["\x1b[15^"] = KeyDownEvent{Sym: KeyF5, Mod: Ctrl}
-	d.table["\x1b[17^"] = KeyDownEvent{Sym: KeyF6, Mod: Ctrl}
-	d.table["\x1b[18^"] = KeyDownEvent{Sym: KeyF7, Mod: Ctrl}
-	d.table["\x1b[19^"] = KeyDownEvent{Sym: KeyF8, Mod: Ctrl}
-	d.table["\x1b[20^"] = KeyDownEvent{Sym: KeyF9, Mod: Ctrl}
-	d.table["\x1b[21^"] = KeyDownEvent{Sym: KeyF10, Mod: Ctrl}
-	d.table["\x1b[23^"] = KeyDownEvent{Sym: KeyF11, Mod: Ctrl}
-	d.table["\x1b[24^"] = KeyDownEvent{Sym: KeyF12, Mod: Ctrl}
-	d.table["\x1b[25^"] = KeyDownEvent{Sym: KeyF13, Mod: Ctrl}
-	d.table["\x1b[26^"] = KeyDownEvent{Sym: KeyF14, Mod: Ctrl}
-	d.table["\x1b[28^"] = KeyDownEvent{Sym: KeyF15, Mod: Ctrl}
-	d.table["\x1b[29^"] = KeyDownEvent{Sym: KeyF16, Mod: Ctrl}
-	d.table["\x1b[31^"] = KeyDownEvent{Sym: KeyF17, Mod: Ctrl}
-	d.table["\x1b[32^"] = KeyDownEvent{Sym: KeyF18, Mod: Ctrl}
-	d.table["\x1b[33^"] = KeyDownEvent{Sym: KeyF19, Mod: Ctrl}
-	d.table["\x1b[34^"] = KeyDownEvent{Sym: KeyF20, Mod: Ctrl}
-	d.table["\x1b[23@"] = KeyDownEvent{Sym: KeyF11, Mod: Shift | Ctrl}
-	d.table["\x1b[24@"] = KeyDownEvent{Sym: KeyF12, Mod: Shift | Ctrl}
-	d.table["\x1b[25@"] = KeyDownEvent{Sym: KeyF13, Mod: Shift | Ctrl}
-	d.table["\x1b[26@"] = KeyDownEvent{Sym: KeyF14, Mod: Shift | Ctrl}
-	d.table["\x1b[28@"] = KeyDownEvent{Sym: KeyF15, Mod: Shift | Ctrl}
-	d.table["\x1b[29@"] = KeyDownEvent{Sym: KeyF16, Mod: Shift | Ctrl}
-	d.table["\x1b[31@"] = KeyDownEvent{Sym: KeyF17, Mod: Shift | Ctrl}
-	d.table["\x1b[32@"] = KeyDownEvent{Sym: KeyF18, Mod: Shift | Ctrl}
-	d.table["\x1b[33@"] = KeyDownEvent{Sym: KeyF19, Mod: Shift | Ctrl}
-	d.table["\x1b[34@"] = KeyDownEvent{Sym: KeyF20, Mod: Shift | Ctrl}
+	urxvt["\x1b[23$"] = KeyDownEvent{Sym: KeyF11, Mod: Shift}
+	urxvt["\x1b[24$"] = KeyDownEvent{Sym: KeyF12, Mod: Shift}
+	urxvt["\x1b[25$"] = KeyDownEvent{Sym: KeyF13, Mod: Shift}
+	urxvt["\x1b[26$"] = KeyDownEvent{Sym: KeyF14, Mod: Shift}
+	urxvt["\x1b[28$"] = KeyDownEvent{Sym: KeyF15, Mod: Shift}
+	urxvt["\x1b[29$"] = KeyDownEvent{Sym: KeyF16, Mod: Shift}
+	urxvt["\x1b[31$"] = KeyDownEvent{Sym: KeyF17, Mod: Shift}
+	urxvt["\x1b[32$"] = KeyDownEvent{Sym: KeyF18, Mod: Shift}
+	urxvt["\x1b[33$"] = KeyDownEvent{Sym: KeyF19, Mod: Shift}
+	urxvt["\x1b[34$"] = KeyDownEvent{Sym: KeyF20, Mod: Shift}
+	urxvt["\x1b[11^"] = KeyDownEvent{Sym: KeyF1, Mod: Ctrl}
+	urxvt["\x1b[12^"] = KeyDownEvent{Sym: KeyF2, Mod: Ctrl}
+	urxvt["\x1b[13^"] = KeyDownEvent{Sym: KeyF3, Mod: Ctrl}
+	urxvt["\x1b[14^"] = KeyDownEvent{Sym: KeyF4, Mod: Ctrl}
+	urxvt["\x1b[15^"] = KeyDownEvent{Sym: KeyF5, Mod: Ctrl}
+	urxvt["\x1b[17^"] = KeyDownEvent{Sym: KeyF6, Mod: Ctrl}
+	urxvt["\x1b[18^"] = KeyDownEvent{Sym: KeyF7, Mod: Ctrl}
+	urxvt["\x1b[19^"] = KeyDownEvent{Sym: KeyF8, Mod: Ctrl}
+	urxvt["\x1b[20^"] = KeyDownEvent{Sym: KeyF9, Mod: Ctrl}
+	urxvt["\x1b[21^"] = KeyDownEvent{Sym: KeyF10, Mod: Ctrl}
+	urxvt["\x1b[23^"] = KeyDownEvent{Sym: KeyF11, Mod: Ctrl}
+	urxvt["\x1b[24^"] = KeyDownEvent{Sym: KeyF12, Mod: Ctrl}
+	urxvt["\x1b[25^"] = KeyDownEvent{Sym: KeyF13, Mod: Ctrl}
+	urxvt["\x1b[26^"] = KeyDownEvent{Sym: KeyF14, Mod: Ctrl}
+	urxvt["\x1b[28^"] = KeyDownEvent{Sym: KeyF15, Mod: Ctrl}
+	urxvt["\x1b[29^"] = KeyDownEvent{Sym: KeyF16, Mod: Ctrl}
+	urxvt["\x1b[31^"] = KeyDownEvent{Sym: KeyF17, Mod: Ctrl}
+	urxvt["\x1b[32^"] = KeyDownEvent{Sym: KeyF18, Mod: Ctrl}
+	urxvt["\x1b[33^"] = KeyDownEvent{Sym: KeyF19, Mod: Ctrl}
+	urxvt["\x1b[34^"] = KeyDownEvent{Sym: KeyF20, Mod: Ctrl}
+	urxvt["\x1b[23@"] = KeyDownEvent{Sym: KeyF11, Mod: Shift | Ctrl}
+	urxvt["\x1b[24@"] = KeyDownEvent{Sym: KeyF12, Mod: Shift | Ctrl}
+	urxvt["\x1b[25@"] = KeyDownEvent{Sym: KeyF13, Mod: Shift | Ctrl}
+	urxvt["\x1b[26@"] = KeyDownEvent{Sym: KeyF14, Mod: Shift | Ctrl}
+	urxvt["\x1b[28@"] = KeyDownEvent{Sym: KeyF15, Mod: Shift | Ctrl}
+	urxvt["\x1b[29@"] = KeyDownEvent{Sym: KeyF16, Mod: Shift | Ctrl}
+	urxvt["\x1b[31@"] = KeyDownEvent{Sym: KeyF17, Mod: Shift | Ctrl}
+	urxvt["\x1b[32@"] = KeyDownEvent{Sym: KeyF18, Mod: Shift | Ctrl}
+	urxvt["\x1b[33@"] = KeyDownEvent{Sym: KeyF19, Mod: Shift | Ctrl}
+	urxvt["\x1b[34@"] = KeyDownEvent{Sym: KeyF20, Mod: Shift | Ctrl}
+
+	// Linux console keys
+	// See console_codes(4)
+	linux := profileTable(keys.linux)
+	linux["\x1b[[A"] = KeyDownEvent{Sym: KeyF1}
+	linux["\x1b[[B"] = KeyDownEvent{Sym: KeyF2}
+	linux["\x1b[[C"] = KeyDownEvent{Sym: KeyF3}
+	linux["\x1b[[D"] = KeyDownEvent{Sym: KeyF4}
+	linux["\x1b[[E"] = KeyDownEvent{Sym: KeyF5}
+
+	// Sun function keys
+	// See https://invisible-island.net/xterm/ctlseqs/ctlseqs.html
+	if flags&FlagSunKeys != 0 || strings.HasPrefix(p.term, "sun") {
+		sunKeys := map[string]KeyDownEvent{
+			"2": {Sym: KeyInsert}, "3": {Sym: KeyDelete},
+			"214": {Sym: KeyHome}, "220": {Sym: KeyEnd},
+			"216": {Sym: KeyPgUp}, "222": {Sym: KeyPgDown},
+			"224": {Sym: KeyF1}, "225": {Sym: KeyF2},
+			"226": {Sym: KeyF3}, "227": {Sym: KeyF4},
+			"228": {Sym: KeyF5}, "229": {Sym: KeyF6},
+			"230": {Sym: KeyF7}, "231": {Sym: KeyF8},
+			"232": {Sym: KeyF9}, "233": {Sym: KeyF10},
+			"192": {Sym: KeyF11}, "193": {Sym: KeyF12},
+		}
+		for k, v := range sunKeys {
+			p.table["\x1b["+k+"z"] = v
+		}
+	}
+
+	// Custom C0 control keys
+	for c, k := range p.c0 {
+		p.table[string(c)] = k
+	}
+
+	// Control pictures
+	// See https://www.unicode.org/charts/PDF/U2400.pdf
+	if flags&FlagCtrlPictures != 0 {
+		for c := ansi.NUL; c <= ansi.US; c++ {
+			p.table[string(rune(0x2400+int(c)))] = p.table[string(byte(c))]
+		}
+		p.table["\u2420"] = sp  // ␠
+		p.table["\u2421"] = del // ␡
+	}
 
 	// Register Alt + <key> combinations
-	for k, v := range d.table {
+	// Collect them first, entries added to a map while ranging over it might
+	// be visited too and get prefixed more than once.
+	alt := make(map[string]KeyDownEvent, len(p.table))
+	for k, v := range p.table {
 		v.Mod |= Alt
-		d.table["\x1b"+k] = v
+		alt["\x1b"+k] = v
+	}
+	for k, v := range alt {
+		p.table[k] = v
 	}
 
 	// Register terminfo keys
 	if flags&FlagNoTerminfo == 0 {
-		d.registerTerminfoKeys()
+		p.registerTerminfoKeys()
+	}
+
+	// Custom keys
+	for seq := range p.removed {
+		delete(p.table, seq)
+	}
+	for seq, k := range p.keys {
+		p.table[seq] = k
+	}
+
+	p.excluded = map[string]struct{}{}
+	for seq := range excluded {
+		if _, ok := p.table[seq]; !ok {
+			p.excluded[seq] = struct{}{}
+		}
+	}
+
+	p.trie = newKeyTrie(p.table)
+}
+
+// keyEventKind is the kind of a key event reported by a sequence.
+type keyEventKind uint
+
+// Key event kinds as defined by the Kitty keyboard protocol event type
+// sub-parameter i.e. CSI <number> ; <modifiers> : <kind> <func>
+const (
+	keyPress   keyEventKind = 1
+	keyRepeat  keyEventKind = 2
+	keyRelease keyEventKind = 3
+)
+
+// event returns the key as an event of the given kind.
+func (kind keyEventKind) event(k KeyDownEvent) Event {
+	switch kind {
+	case keyRepeat:
+		k.IsRepeat = true
+	case keyRelease:
+		return KeyUpEvent(k)
+	}
+	return k
+}
+
+// lookupEventKind looks up a sequence reporting the event kind, e.g. a Kitty
+// key release CSI 1 ; 2 : 3 A, in the key table without the event kind. The
+// matched key is reported as the right event type.
+func (p *Parser) lookupEventKind(seq []byte) (Event, bool) {
+	canon, kind, ok := splitKeyEventKind(seq)
+	if !ok {
+		return nil, false
+	}
+
+	k, ok := p.table[canon]
+	if !ok {
+		return nil, false
+	}
+
+	return kind.event(k), true
+}
+
+// splitKeyEventKind splits the event kind sub-parameter from a CSI key
+// sequence and returns the sequence as it would be reported without it.
+//
+//	CSI 1 ; 2 : 3 A -> CSI 1 ; 2 A
+//	CSI 1 ; 1 : 3 A -> CSI A
+//	CSI 3 ; 1 : 2 ~ -> CSI 3 ~
+func splitKeyEventKind(seq []byte) (string, keyEventKind, bool) {
+	var prefix string
+	switch {
+	case len(seq) > 0 && seq[0] == ansi.CSI:
+		prefix, seq = "\x1b[", seq[1:]
+	case len(seq) > 1 && seq[0] == ansi.ESC && seq[1] == '[':
+		prefix, seq = "\x1b[", seq[2:]
+	default:
+		return "", 0, false
+	}
+
+	if len(seq) < 2 || bytes.IndexByte(seq, ':') < 0 {
+		return "", 0, false
+	}
+
+	final := seq[len(seq)-1]
+	params := ansi.Params(seq[:len(seq)-1])
+	if len(params) != 2 || len(params[1]) != 2 {
+		return "", 0, false
+	}
+
+	kind := keyEventKind(params[1][1])
+	if kind < keyPress || kind > keyRelease {
+		return "", 0, false
 	}
+
+	num := strconv.FormatUint(uint64(params[0][0]), 10)
+	mod := params[1][0]
+	if mod <= 1 {
+		if num == "1" && final != '~' {
+			// CSI 1 ; 1 <func> is reported as CSI <func>
+			return prefix + string(final), kind, true
+		}
+		return prefix + num + string(final), kind, true
+	}
+
+	return prefix + num + ";" + strconv.FormatUint(uint64(mod), 10) + string(final), kind, true
 }
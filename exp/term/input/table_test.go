@@ -0,0 +1,506 @@
+package input
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/exp/term/ansi"
+)
+
+func TestNavigationKeysCrossForm(t *testing.T) {
+	cases := []struct {
+		want KeyDownEvent
+		seqs []string
+	}{
+		{KeyDownEvent{Sym: KeyHome}, []string{"\x1b[H", "\x1bOH", "\x1b[7~"}},
+		{KeyDownEvent{Sym: KeyHome, Mod: Shift}, []string{"\x1b[1;2H", "\x1bO2H", "\x1b[7;2~", "\x1b[7$"}},
+		{KeyDownEvent{Sym: KeyHome, Mod: Alt}, []string{"\x1b[1;3H", "\x1bO3H", "\x1b[7;3~"}},
+		{KeyDownEvent{Sym: KeyHome, Mod: Ctrl}, []string{"\x1b[1;5H", "\x1bO5H", "\x1b[7;5~", "\x1b[7^"}},
+		{KeyDownEvent{Sym: KeyHome, Mod: Shift | Ctrl}, []string{"\x1b[1;6H", "\x1bO6H", "\x1b[7;6~", "\x1b[7@"}},
+		{KeyDownEvent{Sym: KeyEnd}, []string{"\x1b[F", "\x1bOF", "\x1b[8~"}},
+		{KeyDownEvent{Sym: KeyEnd, Mod: Shift}, []string{"\x1b[1;2F", "\x1bO2F", "\x1b[8;2~", "\x1b[8$"}},
+		{KeyDownEvent{Sym: KeyEnd, Mod: Alt}, []string{"\x1b[1;3F", "\x1bO3F", "\x1b[8;3~"}},
+		{KeyDownEvent{Sym: KeyEnd, Mod: Ctrl}, []string{"\x1b[1;5F", "\x1bO5F", "\x1b[8;5~", "\x1b[8^"}},
+		{KeyDownEvent{Sym: KeyEnd, Mod: Shift | Ctrl}, []string{"\x1b[1;6F", "\x1bO6F", "\x1b[8;6~", "\x1b[8@"}},
+		{KeyDownEvent{Sym: KeyUp, Mod: Ctrl}, []string{"\x1b[1;5A", "\x1bO5A"}},
+		{KeyDownEvent{Sym: KeyPgUp, Mod: Shift}, []string{"\x1b[5;2~", "\x1b[5$"}},
+		{KeyDownEvent{Sym: KeyPgUp, Mod: Ctrl}, []string{"\x1b[5;5~", "\x1b[5^"}},
+		{KeyDownEvent{Sym: KeyPgUp, Mod: Shift | Ctrl}, []string{"\x1b[5;6~", "\x1b[5@"}},
+		{KeyDownEvent{Sym: KeyPgDown, Mod: Shift}, []string{"\x1b[6;2~", "\x1b[6$"}},
+		{KeyDownEvent{Sym: KeyPgDown, Mod: Ctrl}, []string{"\x1b[6;5~", "\x1b[6^"}},
+		{KeyDownEvent{Sym: KeyPgDown, Mod: Shift | Ctrl}, []string{"\x1b[6;6~", "\x1b[6@"}},
+	}
+
+	for _, c := range cases {
+		for _, seq := range c.seqs {
+			// The sequence on its own.
+			if got := readEvents(t, 0, seq); !reflect.DeepEqual(got, []Event{c.want}) {
+				t.Errorf("%q: expected %v, got %v", seq, c.want, got)
+			}
+
+			// The sequence followed by other input.
+			want := []Event{c.want, KeyDownEvent{Rune: 'x'}}
+			if got := readEvents(t, 0, seq+"x"); !reflect.DeepEqual(got, want) {
+				t.Errorf("%q: expected %v, got %v", seq+"x", want, got)
+			}
+		}
+	}
+}
+
+func TestShiftTab(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want KeyDownEvent
+	}{
+		{"\x1b[Z", KeyDownEvent{Sym: KeyTab, Mod: Shift}},
+		{"\x1bOZ", KeyDownEvent{Sym: KeyTab, Mod: Shift}},
+		{"\x1b[1;2Z", KeyDownEvent{Sym: KeyTab, Mod: Shift}},
+		{"\x1b[1;5Z", KeyDownEvent{Sym: KeyTab, Mod: Shift | Ctrl}},
+		{"\x1b[1;6Z", KeyDownEvent{Sym: KeyTab, Mod: Shift | Ctrl}},
+		{"\x1b[1;3Z", KeyDownEvent{Sym: KeyTab, Mod: Shift | Alt}},
+		{"\x1b\x1b[Z", KeyDownEvent{Sym: KeyTab, Mod: Shift | Alt}},
+	}
+
+	for _, c := range cases {
+		// Table lookup
+		if got := DecodeString(c.seq); !reflect.DeepEqual(got, []Event{c.want}) {
+			t.Errorf("%q: expected %v, got %v", c.seq, c.want, got)
+		}
+		// Parser
+		if _, e := ParseSequence([]byte(c.seq)); !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %v, got %v", c.seq, c.want, e)
+		}
+		// Followed by other input
+		want := []Event{c.want, KeyDownEvent{Rune: 'x'}}
+		if got := DecodeString(c.seq + "x"); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %v, got %v", c.seq+"x", want, got)
+		}
+	}
+}
+
+func TestCtrlPictures(t *testing.T) {
+	cases := []struct {
+		flags int
+		in    string
+		want  []Event
+	}{
+		{FlagCtrlPictures, "␀", []Event{KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl}}},
+		{FlagCtrlPictures | FlagCtrlAt, "␀", []Event{KeyDownEvent{Rune: '@', Mod: Ctrl}}},
+		{FlagCtrlPictures, "␁", []Event{KeyDownEvent{Rune: 'a', Mod: Ctrl}}},
+		{FlagCtrlPictures, "␉␍", []Event{KeyDownEvent{Sym: KeyTab}, KeyDownEvent{Sym: KeyEnter}}},
+		{FlagCtrlPictures, "␛", []Event{KeyDownEvent{Sym: KeyEscape}}},
+		{FlagCtrlPictures, "␟", []Event{KeyDownEvent{Rune: '_', Mod: Ctrl}}},
+		{FlagCtrlPictures, "␠␡", []Event{KeyDownEvent{Sym: KeySpace, Rune: ' '}, KeyDownEvent{Sym: KeyBackspace}}},
+		{FlagCtrlPictures, "\x1b␁", []Event{KeyDownEvent{Rune: 'a', Mod: Ctrl | Alt}}},
+		{FlagCtrlPictures, "a␃b", []Event{KeyDownEvent{Rune: 'a'}, KeyDownEvent{Rune: 'c', Mod: Ctrl}, KeyDownEvent{Rune: 'b'}}},
+
+		// Without the flag, control pictures are regular runes.
+		{0, "␀", []Event{KeyDownEvent{Rune: '␀'}}},
+	}
+
+	for _, c := range cases {
+		if got := DecodeStringWith(c.in, c.flags); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: expected %v, got %v", c.in, c.want, got)
+		}
+	}
+}
+
+func TestModifiedFunctionKeysWithoutLeadingParam(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want KeyDownEvent
+	}{
+		{"\x1b[P", KeyDownEvent{Sym: KeyF1}},
+		{"\x1b[2P", KeyDownEvent{Sym: KeyF1, Mod: Shift}},
+		{"\x1b[5Q", KeyDownEvent{Sym: KeyF2, Mod: Ctrl}},
+		{"\x1b[3R", KeyDownEvent{Sym: KeyF3, Mod: Alt}},
+		{"\x1b[6S", KeyDownEvent{Sym: KeyF4, Mod: Shift | Ctrl}},
+		{"\x1bO2P", KeyDownEvent{Sym: KeyF1, Mod: Shift}},
+		{"\x1bO5S", KeyDownEvent{Sym: KeyF4, Mod: Ctrl}},
+		{"\x1bO3A", KeyDownEvent{Sym: KeyUp, Mod: Alt}},
+		{"\x1bO5M", KeyDownEvent{Sym: KeyKpEnter, Mod: Ctrl}},
+		{"\x1b[1;2P", KeyDownEvent{Sym: KeyF1, Mod: Shift}},
+	}
+
+	for _, c := range cases {
+		if _, e := ParseSequence([]byte(c.seq)); !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected parser %v, got %v", c.seq, c.want, e)
+		}
+		want := []Event{c.want, KeyDownEvent{Rune: 'x'}}
+		if got := DecodeString(c.seq + "x"); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %v, got %v", c.seq+"x", want, got)
+		}
+	}
+}
+
+func TestSunFunctionKeys(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want KeyDownEvent
+	}{
+		{"\x1b[224z", KeyDownEvent{Sym: KeyF1}},
+		{"\x1b[227z", KeyDownEvent{Sym: KeyF4}},
+		{"\x1b[233z", KeyDownEvent{Sym: KeyF10}},
+		{"\x1b[192z", KeyDownEvent{Sym: KeyF11}},
+		{"\x1b[193z", KeyDownEvent{Sym: KeyF12}},
+		{"\x1b[214z", KeyDownEvent{Sym: KeyHome}},
+		{"\x1b[222z", KeyDownEvent{Sym: KeyPgDown}},
+		{"\x1b\x1b[225z", KeyDownEvent{Sym: KeyF2, Mod: Alt}},
+	}
+
+	sun := newDriver("sun", FlagNoTerminfo)
+	for _, c := range cases {
+		if got := DecodeStringWith(c.seq, FlagSunKeys); !reflect.DeepEqual(got, []Event{c.want}) {
+			t.Errorf("%q: expected %v, got %v", c.seq, c.want, got)
+		}
+		if got := sun.parser.decode([]byte(c.seq)); !reflect.DeepEqual(got, []Event{c.want}) {
+			t.Errorf("%q: expected %v with TERM=sun, got %v", c.seq, c.want, got)
+		}
+	}
+
+	// Without the flag, these are unknown sequences.
+	if got := DecodeString("\x1b[224z"); !reflect.DeepEqual(got, []Event{UnknownCsiEvent("\x1b[224z")}) {
+		t.Errorf("expected an unknown sequence, got %v", got)
+	}
+}
+
+func TestTableKeyEventKind(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want Event
+	}{
+		{"\x1b[1;1:3A", KeyUpEvent{Sym: KeyUp}},
+		{"\x1b[1;2:3A", KeyUpEvent{Sym: KeyUp, Mod: Shift}},
+		{"\x1b[1;5:2H", KeyDownEvent{Sym: KeyHome, Mod: Ctrl, IsRepeat: true}},
+		{"\x1b[1;1:1P", KeyDownEvent{Sym: KeyF1}},
+		{"\x1b[3;1:3~", KeyUpEvent{Sym: KeyDelete}},
+		{"\x1b[5;3:3~", KeyUpEvent{Sym: KeyPgUp, Mod: Alt}},
+		{"\x1b[15;1:2~", KeyDownEvent{Sym: KeyF5, IsRepeat: true}},
+		{"\x1b[1;2:3Z", KeyUpEvent{Sym: KeyTab, Mod: Shift}},
+	}
+
+	for _, c := range cases {
+		if got := DecodeString(c.seq); !reflect.DeepEqual(got, []Event{c.want}) {
+			t.Errorf("%q: expected %v, got %v", c.seq, c.want, got)
+		}
+		if _, e := ParseSequence([]byte(c.seq)); !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected parser %v, got %v", c.seq, c.want, e)
+		}
+	}
+
+	// The release of a flag dependent key uses the table
+	want := []Event{KeyUpEvent{Sym: KeyFind}}
+	if got := DecodeStringWith("\x1b[1;1:3~", FlagFind); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestModifiedKeysPreserveFields(t *testing.T) {
+	mods := []Mod{Shift, Alt, Ctrl, Shift | Ctrl, Shift | Alt | Ctrl, Meta}
+
+	for _, flags := range []int{0, FlagSpace} {
+		d := newDriver("", flags)
+		for _, m := range mods {
+			xm := strconv.FormatUint(uint64(encodeXTermModifier(m)), 10)
+			for _, c := range []struct {
+				base, seq string
+			}{
+				{"\x1b[A", "\x1b[1;" + xm + "A"},
+				{"\x1b[Z", "\x1b[1;" + xm + "Z"},
+				{"\x1b[P", "\x1b[" + xm + "P"},
+				{"\x1bOM", "\x1bO" + xm + "M"},
+				{"\x1b[3~", "\x1b[3;" + xm + "~"},
+				{"\r", "\x1b[27;" + xm + ";13~"},
+				{" ", "\x1b[27;" + xm + ";32~"},
+			} {
+				want, ok := d.parser.table[c.base]
+				if !ok {
+					t.Fatalf("flags %d: missing base key %q", flags, c.base)
+				}
+				want.Mod |= m
+				if got := d.parser.table[c.seq]; !reflect.DeepEqual(got, want) {
+					t.Errorf("flags %d: %q: expected %#v, got %#v", flags, c.seq, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestCtrlSpace(t *testing.T) {
+	cases := []struct {
+		flags int
+		in    string
+		want  KeyDownEvent
+	}{
+		{0, "\x00", KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl}},
+		{0, "\x1b\x00", KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl | Alt}},
+		{0, "\x1b[27;5;32~", KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl}},
+		{0, "\x1b[27;3;32~", KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Alt}},
+		{0, "\x1b[32;5u", KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl}},
+		{FlagSpace, "\x00", KeyDownEvent{Rune: ' ', Mod: Ctrl}},
+		{FlagSpace, "\x1b[27;5;32~", KeyDownEvent{Rune: ' ', Mod: Ctrl}},
+		{FlagCtrlAt, "\x00", KeyDownEvent{Rune: '@', Mod: Ctrl}},
+	}
+
+	for _, c := range cases {
+		got := DecodeStringWith(c.in, c.flags)
+		if want := []Event{c.want}; !reflect.DeepEqual(got, want) {
+			t.Errorf("flags %d: %q: expected %#v, got %#v", c.flags, c.in, want, got)
+		}
+	}
+}
+
+func TestTableHasNoZeroKeys(t *testing.T) {
+	terms := []string{"", "xterm-256color", "screen", "linux", "rxvt-unicode"}
+	flags := []int{
+		0,
+		FlagCtrlPictures | FlagSunKeys,
+		FlagSpace | FlagCtrlAt | FlagFind | FlagSelect | FlagBackspace,
+		FlagCtrlI | FlagCtrlM | FlagCtrlOpenBracket | FlagNoXTerm,
+	}
+
+	for _, term := range terms {
+		for _, f := range flags {
+			d := newDriver(term, f)
+			for seq, k := range d.parser.table {
+				if k.Sym == KeyNone && k.Rune == 0 {
+					t.Errorf("term %q, flags %d: %q maps to a zero key %#v", term, f, seq, k)
+				}
+			}
+		}
+	}
+
+	// Terminfo keys are only registered when the terminfo database is
+	// available, check the capabilities table directly.
+	for _, f := range flags {
+		for name, k := range defaultTerminfoKeys(f) {
+			if k.Sym == KeyNone && k.Rune == 0 {
+				t.Errorf("flags %d: terminfo capability %q maps to a zero key %#v", f, name, k)
+			}
+		}
+	}
+}
+
+func TestKeypadEnterAndEqual(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want KeyDownEvent
+		str  string
+	}{
+		{"\x1bOM", KeyDownEvent{Sym: KeyKpEnter}, "kpenter"},
+		{"\x1bOX", KeyDownEvent{Sym: KeyKpEqual}, "kpequal"},
+		{"\x1bO2M", KeyDownEvent{Sym: KeyKpEnter, Mod: Shift}, "shift+kpenter"},
+		{"\x1bO5M", KeyDownEvent{Sym: KeyKpEnter, Mod: Ctrl}, "ctrl+kpenter"},
+		{"\x1bO3X", KeyDownEvent{Sym: KeyKpEqual, Mod: Alt}, "alt+kpequal"},
+		{"\x1bO6X", KeyDownEvent{Sym: KeyKpEqual, Mod: Shift | Ctrl}, "ctrl+shift+kpequal"},
+		{"\x1b\x1bOX", KeyDownEvent{Sym: KeyKpEqual, Mod: Alt}, "alt+kpequal"},
+	}
+
+	for _, c := range cases {
+		if got := DecodeString(c.seq); !reflect.DeepEqual(got, []Event{c.want}) {
+			t.Errorf("%q: expected %v, got %v", c.seq, c.want, got)
+		}
+		if _, e := ParseSequence([]byte(c.seq)); !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected parser %v, got %v", c.seq, c.want, e)
+		}
+		if s := c.want.String(); s != c.str {
+			t.Errorf("%q: expected %q, got %q", c.seq, c.str, s)
+		}
+	}
+}
+
+func TestSetC0Handler(t *testing.T) {
+	d := newDriver("", 0)
+	interrupt := KeyDownEvent{Sym: KeyPause}
+	d.SetC0Handler(ansi.ETX, interrupt)
+
+	want := []Event{
+		interrupt,
+		KeyDownEvent{Sym: KeyPause, Mod: Alt},
+		KeyDownEvent{Rune: 'd', Mod: Ctrl},
+	}
+	if got := d.parser.decode([]byte("\x03\x1b\x03\x04")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Overrides win over the flags.
+	d = newDriver("", FlagCtrlM)
+	d.SetC0Handler(ansi.CR, KeyDownEvent{Sym: KeyKpEnter})
+	if got, want := d.parser.decode([]byte("\r")), []Event{KeyDownEvent{Sym: KeyKpEnter}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Only C0 control characters can be overridden.
+	d.SetC0Handler('a', KeyDownEvent{Sym: KeyPause})
+	if got, want := d.parser.decode([]byte("a")), []Event{KeyDownEvent{Rune: 'a'}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRegisterKey(t *testing.T) {
+	d := newDriver("", 0)
+
+	// Override a built-in key and add a new one.
+	if err := d.RegisterKey("\x1b[A", KeyDownEvent{Sym: KeyKpUp}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.RegisterKeys(map[string]KeyDownEvent{
+		"\x1b[99~": {Sym: KeyF20},
+		"\x1b[A":   {Sym: KeyKpUp, Mod: Shift}, // replaces the previous one
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []Event{
+		KeyDownEvent{Sym: KeyKpUp, Mod: Shift},
+		KeyDownEvent{Sym: KeyF20},
+		KeyDownEvent{Sym: KeyF20},
+		KeyDownEvent{Sym: KeyDown},
+	}
+	if got := d.parser.decode([]byte("\x1b[A\x1b[99~\x9b99~\x1b[B")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Custom keys survive rebuilding the key table.
+	d.SetC0Handler(ansi.ETX, KeyDownEvent{Sym: KeyPause})
+	if got, want := d.parser.decode([]byte("\x1b[99~")), []Event{KeyDownEvent{Sym: KeyF20}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Remove a custom and a built-in table only key.
+	d.UnregisterKey("\x1b[99~")
+	d.UnregisterKey("\x1b[7$")
+	want = []Event{UnknownCsiEvent("\x1b[99~"), UnknownCsiEvent("\x1b[7$")}
+	if got := d.parser.decode([]byte("\x1b[99~\x1b[7$")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Registering a removed key brings it back.
+	if err := d.RegisterKey("\x1b[7$", KeyDownEvent{Sym: KeyHome, Mod: Shift}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := d.parser.decode([]byte("\x1b[7$")), []Event{KeyDownEvent{Sym: KeyHome, Mod: Shift}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRegisterKeyInvalid(t *testing.T) {
+	d := newDriver("", 0)
+	for _, seq := range []string{"", "a", " \x1b", "é"} {
+		if err := d.RegisterKey(seq, KeyDownEvent{Sym: KeyF1}); !errors.Is(err, ErrInvalidSequence) {
+			t.Errorf("%q: expected ErrInvalidSequence, got %v", seq, err)
+		}
+	}
+
+	// None of the keys are registered when one is invalid.
+	err := d.RegisterKeys(map[string]KeyDownEvent{"\x1b[99~": {Sym: KeyF20}, "x": {Sym: KeyF1}})
+	if !errors.Is(err, ErrInvalidSequence) {
+		t.Errorf("expected ErrInvalidSequence, got %v", err)
+	}
+	if got, want := d.parser.decode([]byte("x")), []Event{KeyDownEvent{Rune: 'x'}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if _, ok := d.parser.table["\x1b[99~"]; ok {
+		t.Errorf("expected the valid key not to be registered")
+	}
+}
+
+func TestLookupTable(t *testing.T) {
+	d := newDriver("", 0)
+	if k, ok := d.Lookup("\x1b[A"); !ok || k != (KeyDownEvent{Sym: KeyUp}) {
+		t.Errorf("expected up, got %v, %v", k, ok)
+	}
+	if k, ok := d.Lookup("\x1b[97u"); ok {
+		t.Errorf("expected Kitty keys not to be in the table, got %v", k)
+	}
+
+	// The table is a copy.
+	table := d.Table()
+	if len(table) != len(d.parser.table) {
+		t.Fatalf("expected %d keys, got %d", len(d.parser.table), len(table))
+	}
+	table["\x1b[A"] = KeyDownEvent{Sym: KeyF1}
+	delete(table, "\x1b[B")
+	if k, _ := d.Lookup("\x1b[A"); k != (KeyDownEvent{Sym: KeyUp}) {
+		t.Errorf("expected the table not to change, got %v", k)
+	}
+	if _, ok := d.Lookup("\x1b[B"); !ok {
+		t.Errorf("expected the table not to change")
+	}
+
+	// Tables differ across flags.
+	tab, ctrlI := newDriver("", 0).Table(), newDriver("", FlagCtrlI).Table()
+	if tab["\t"] == ctrlI["\t"] {
+		t.Errorf("expected different tab keys, got %v", tab["\t"])
+	}
+
+	// Custom keys are reported.
+	if err := d.RegisterKey("\x1b[99~", KeyDownEvent{Sym: KeyF20}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if k, ok := d.Lookup("\x1b[99~"); !ok || k != (KeyDownEvent{Sym: KeyF20}) {
+		t.Errorf("expected f20, got %v, %v", k, ok)
+	}
+}
+
+func TestSs3KeysNoShadowing(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want KeyDownEvent
+	}{
+		{"\x1bOA", KeyDownEvent{Sym: KeyUp}},             // DECCKM
+		{"\x1bOa", KeyDownEvent{Sym: KeyUp, Mod: Ctrl}},  // URxvt
+		{"\x1bO5A", KeyDownEvent{Sym: KeyUp, Mod: Ctrl}}, // XTerm without the leading parameter
+		{"\x1bO2A", KeyDownEvent{Sym: KeyUp, Mod: Shift}},
+		{"\x1bO10A", KeyDownEvent{Sym: KeyUp, Mod: Shift | Meta}},
+		{"\x1bOE", KeyDownEvent{Sym: KeyBegin}},
+		{"\x1bO5E", KeyDownEvent{Sym: KeyBegin, Mod: Ctrl}},
+		{"\x1bOP", KeyDownEvent{Sym: KeyF1}},
+		{"\x1bO3P", KeyDownEvent{Sym: KeyF1, Mod: Alt}},
+		{"\x1bOM", KeyDownEvent{Sym: KeyKpEnter}},
+		{"\x1bOp", KeyDownEvent{Sym: KeyKp0}},
+		{"\x1bOy", KeyDownEvent{Sym: KeyKp9}},
+		{"\x1b\x1bOa", KeyDownEvent{Sym: KeyUp, Mod: Ctrl | Alt}},
+	}
+
+	d := newDriver("", FlagNoTerminfo)
+	for _, c := range cases {
+		if got, want := d.parser.decode([]byte(c.seq)), []Event{c.want}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %v, got %v", c.seq, want, got)
+		}
+		if strings.HasPrefix(c.seq, "\x1bO") {
+			// The 8-bit SS3 form
+			seq := "\x8f" + c.seq[2:]
+			if got, want := d.parser.decode([]byte(seq)), []Event{c.want}; !reflect.DeepEqual(got, want) {
+				t.Errorf("%q: expected %v, got %v", seq, want, got)
+			}
+		}
+	}
+
+	// Every SS3 key in the table resolves to its own key, whether it's
+	// matched by the table or decoded by the parser.
+	for _, p := range []Profile{ProfileGeneric, ProfileXTerm, ProfileRxvt, ProfileLinux} {
+		for _, flags := range []int{0, FlagNoXTerm, FlagKeypadNav} {
+			d := newDriver("", flags|FlagNoTerminfo)
+			d.parser.profile = p
+			d.parser.registerKeys(d.parser.flags)
+			for seq, k := range d.parser.table {
+				if !strings.HasPrefix(seq, "\x1bO") && !strings.HasPrefix(seq, "\x1b\x1bO") {
+					continue
+				}
+				if got, want := d.parser.decode([]byte(seq)), []Event{k}; !reflect.DeepEqual(got, want) {
+					t.Errorf("%s, %b: %q: expected %v, got %v", p, flags, seq, want, got)
+				}
+				if !strings.HasPrefix(seq, "\x1bO") {
+					continue
+				}
+				if n, e := parseSequence([]byte(seq), flags); n != len(seq) || !reflect.DeepEqual(e, k) {
+					t.Errorf("%s, %b: %q: expected parser %v, got %d, %v", p, flags, seq, k, n, e)
+				}
+			}
+		}
+	}
+}
@@ -3,8 +3,6 @@ package input
 import (
 	"bytes"
 	"encoding/hex"
-	"fmt"
-	"strings"
 )
 
 // TermcapEvent represents a Termcap response event. Termcap responses are
@@ -19,26 +17,7 @@ type TermcapEvent struct {
 
 // String implements fmt.Stringer.
 func (t TermcapEvent) String() string {
-	var s strings.Builder
-	var i int
-	if !t.IsValid {
-		s.WriteString("!")
-		if len(t.Values) > 0 {
-			s.WriteString(" ")
-		}
-	}
-	for k, v := range t.Values {
-		if i > 0 {
-			s.WriteString(",")
-		}
-		s.WriteString(k)
-		if v != "" {
-			s.WriteString("=")
-			s.WriteString(fmt.Sprintf("%q", v))
-		}
-		i++
-	}
-	return s.String()
+	return EventString(t)
 }
 
 func parseTermcap(data []byte) TermcapEvent {
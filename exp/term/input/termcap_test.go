@@ -0,0 +1,80 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTermcapEvent(t *testing.T) {
+	cases := []struct {
+		name string
+		seq  string
+		want Event
+	}{
+		{
+			"boolean",
+			"\x1bP1+r5463\x1b\\",
+			TermcapEvent{Values: map[string]string{"Tc": ""}, IsValid: true},
+		},
+		{
+			"value",
+			"\x1bP1+r636F6C6F7273=323536\x1b\\",
+			TermcapEvent{Values: map[string]string{"colors": "256"}, IsValid: true},
+		},
+		{
+			"multiple",
+			"\x1bP1+r5463;524742=382F382F38\x1b\\",
+			TermcapEvent{Values: map[string]string{"Tc": "", "RGB": "8/8/8"}, IsValid: true},
+		},
+		{
+			"8-bit",
+			"\x901+r5463\x9c",
+			TermcapEvent{Values: map[string]string{"Tc": ""}, IsValid: true},
+		},
+		{
+			"lowercase hex",
+			"\x1bP1+r636f6c6f7273=323536\x1b\\",
+			TermcapEvent{Values: map[string]string{"colors": "256"}, IsValid: true},
+		},
+		{
+			"unsupported",
+			"\x1bP0+r\x1b\\",
+			TermcapEvent{},
+		},
+		{
+			"unsupported with the query",
+			"\x1bP0+r5463\x1b\\",
+			TermcapEvent{Values: map[string]string{"Tc": ""}},
+		},
+		{
+			"invalid hex entries are skipped",
+			"\x1bP1+rzz;5463\x1b\\",
+			TermcapEvent{Values: map[string]string{"Tc": ""}, IsValid: true},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			n, e := ParseSequence([]byte(c.seq))
+			if n != len(c.seq) {
+				t.Errorf("expected %d bytes, got %d", len(c.seq), n)
+			}
+			if !reflect.DeepEqual(e, c.want) {
+				t.Errorf("expected %#v, got %#v", c.want, e)
+			}
+		})
+	}
+}
+
+func TestTermcapEventString(t *testing.T) {
+	e := TermcapEvent{Values: map[string]string{"colors": "256", "Tc": "", "RGB": "8"}, IsValid: true}
+	for i := 0; i < 10; i++ {
+		if got, want := e.String(), `TermcapEvent{Values: {"RGB": "8", "Tc": "", "colors": "256"}, IsValid: true}`; got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	}
+
+	if got, want := (TermcapEvent{Values: map[string]string{"Tc": ""}}).String(), `TermcapEvent{Values: {"Tc": ""}, IsValid: false}`; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
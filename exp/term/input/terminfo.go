@@ -6,17 +6,17 @@ import (
 	"github.com/xo/terminfo"
 )
 
-func (d *Driver) registerTerminfoKeys() {
-	if d.term == "" {
+func (p *Parser) registerTerminfoKeys() {
+	if p.term == "" {
 		return
 	}
 
-	ti, _ := terminfo.Load(d.term)
+	ti, _ := terminfo.Load(p.term)
 	if ti == nil {
 		return
 	}
 
-	tiTable := defaultTerminfoKeys(d.flags)
+	tiTable := defaultTerminfoKeys(p.flags)
 
 	// Default keys
 	for name, seq := range ti.StringCapsShort() {
@@ -25,7 +25,7 @@ func (d *Driver) registerTerminfoKeys() {
 		}
 
 		if k, ok := tiTable[name]; ok {
-			d.table[string(seq)] = k
+			p.table[string(seq)] = k
 		}
 	}
 
@@ -36,7 +36,7 @@ func (d *Driver) registerTerminfoKeys() {
 		}
 
 		if k, ok := tiTable[name]; ok {
-			d.table[string(seq)] = k
+			p.table[string(seq)] = k
 		}
 	}
 }
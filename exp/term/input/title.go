@@ -0,0 +1,27 @@
+package input
+
+// WindowLabelEvent represents a window title report event. This is the
+// terminal response to a window title query (XTWINOPS 21) i.e. OSC l <label> ST.
+//
+// The label might be empty if the window doesn't have a title.
+type WindowLabelEvent struct {
+	Label string
+}
+
+// String implements fmt.Stringer.
+func (e WindowLabelEvent) String() string {
+	return EventString(e)
+}
+
+// IconLabelEvent represents an icon label report event. This is the terminal
+// response to an icon label query (XTWINOPS 20) i.e. OSC L <label> ST.
+//
+// The label might be empty if the window doesn't have an icon label.
+type IconLabelEvent struct {
+	Label string
+}
+
+// String implements fmt.Stringer.
+func (e IconLabelEvent) String() string {
+	return EventString(e)
+}
@@ -0,0 +1,93 @@
+package input
+
+import "github.com/charmbracelet/x/exp/term/ansi"
+
+// keyTrie is a prefix tree of key sequences. It's compiled from the driver key
+// table and lets the driver find the longest key sequence at the start of the
+// input by advancing one byte at a time, instead of looking up every possible
+// prefix in the table.
+type keyTrie struct {
+	children map[byte]*keyTrie
+	key      KeyDownEvent
+	isKey    bool // whether the node terminates a key sequence
+}
+
+// newKeyTrie compiles a key table into a trie.
+func newKeyTrie(table map[string]KeyDownEvent) *keyTrie {
+	t := &keyTrie{}
+	for seq, k := range table {
+		t.insert(seq, k)
+	}
+	return t
+}
+
+// insert adds a key sequence to the trie.
+func (t *keyTrie) insert(seq string, k KeyDownEvent) {
+	n := t
+	for i := 0; i < len(seq); i++ {
+		if n.children == nil {
+			n.children = map[byte]*keyTrie{}
+		}
+		c, ok := n.children[seq[i]]
+		if !ok {
+			c = &keyTrie{}
+			n.children[seq[i]] = c
+		}
+		n = c
+	}
+	n.key, n.isKey = k, true
+}
+
+// match returns the longest key sequence at the start of b and its length.
+// It stops as soon as no key sequence can match. The leaf result reports
+// whether no longer key sequence starts with the matched one.
+func (t *keyTrie) match(b []byte) (n int, k KeyDownEvent, ok, leaf bool) {
+	if t == nil {
+		return 0, k, false, false
+	}
+	return t.walk(b, 0)
+}
+
+// matchC1 is like match for input that starts with an 8-bit C1 control e.g.
+// CSI (0x9b). The control matches key sequences that start with its 7-bit
+// form i.e. ESC [.
+func (t *keyTrie) matchC1(b []byte) (n int, k KeyDownEvent, ok, leaf bool) {
+	if t == nil || len(b) == 0 || b[0] < 0x80 || b[0] > 0x9f {
+		return 0, k, false, false
+	}
+
+	node := t.children[ansi.ESC]
+	if node == nil {
+		return 0, k, false, false
+	}
+	node = node.children[b[0]-0x40]
+	if node == nil {
+		return 0, k, false, false
+	}
+
+	if node.isKey {
+		n, k, ok = 1, node.key, true
+		leaf = len(node.children) == 0
+	}
+	if wn, wk, wok, wleaf := node.walk(b[1:], 1); wok {
+		n, k, ok, leaf = wn, wk, wok, wleaf
+	}
+	return
+}
+
+// walk advances from the node over b and returns the longest key sequence
+// found. The returned length is offset by off.
+func (t *keyTrie) walk(b []byte, off int) (n int, k KeyDownEvent, ok, leaf bool) {
+	node := t
+	for i := 0; i < len(b); i++ {
+		node = node.children[b[i]]
+		if node == nil {
+			break
+		}
+		if node.isKey {
+			n, k, ok = off+i+1, node.key, true
+			leaf = len(node.children) == 0
+		}
+	}
+	return
+}
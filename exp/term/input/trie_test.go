@@ -0,0 +1,70 @@
+package input
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeyTrieMatch(t *testing.T) {
+	trie := newKeyTrie(map[string]KeyDownEvent{
+		"\x1b":      {Sym: KeyEscape},
+		"\x1b[A":    {Sym: KeyUp},
+		"\x1b[1;5A": {Sym: KeyUp, Mod: Ctrl},
+	})
+
+	cases := []struct {
+		in   string
+		n    int
+		key  KeyDownEvent
+		ok   bool
+		leaf bool
+	}{
+		{"\x1b", 1, KeyDownEvent{Sym: KeyEscape}, true, false},
+		{"\x1b[Ax", 3, KeyDownEvent{Sym: KeyUp}, true, true},
+		{"\x1b[1;5A", 6, KeyDownEvent{Sym: KeyUp, Mod: Ctrl}, true, true},
+		{"\x1b[1;5", 1, KeyDownEvent{Sym: KeyEscape}, true, false},
+		{"x\x1b[A", 0, KeyDownEvent{}, false, false},
+		{"", 0, KeyDownEvent{}, false, false},
+	}
+	for _, c := range cases {
+		n, k, ok, leaf := trie.match([]byte(c.in))
+		if n != c.n || k != c.key || ok != c.ok || leaf != c.leaf {
+			t.Errorf("%q: expected (%d, %v, %v, %v), got (%d, %v, %v, %v)",
+				c.in, c.n, c.key, c.ok, c.leaf, n, k, ok, leaf)
+		}
+	}
+}
+
+func TestDecodeLongestKey(t *testing.T) {
+	// Linux console F1 isn't a well-formed CSI sequence and the generic
+	// profile doesn't know it.
+	d := newDriver("", 0)
+	if err := d.RegisterKey("\x1b[[A", KeyDownEvent{Sym: KeyF1}); err != nil {
+		t.Fatalf("unexpected error registering key: %v", err)
+	}
+
+	want := []Event{
+		KeyDownEvent{Rune: 'a'},
+		KeyDownEvent{Sym: KeyF1},
+		KeyDownEvent{Sym: KeyUp},
+		MouseDownEvent{X: 9, Y: 4, Button: MouseButtonLeft},
+		KeyDownEvent{Sym: KeyEscape},
+	}
+	if got := d.parser.decode([]byte("a\x1b[[A\x1b[A\x1b[<0;10;5M\x1b")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// benchInput is a mix of keys, modified keys, and mouse events.
+var benchInput = []byte(strings.Repeat("hello\x1b[A\x1b[1;5C\x1bOP\x1b[15;2~\x1b[<35;10;5M\x1ba\r", 64))
+
+func BenchmarkDecode(b *testing.B) {
+	d := newDriver("", 0)
+	b.SetBytes(int64(len(benchInput)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.parser.decode(benchInput)
+	}
+}
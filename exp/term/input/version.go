@@ -0,0 +1,42 @@
+package input
+
+import "strings"
+
+// TerminalVersionEvent represents a terminal name and version report. This
+// is the terminal response to a XTVERSION request i.e. CSI > q.
+//
+//	DCS > | <name> ST
+//
+// See https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Functions-using-CSI-_-ordered-by-the-final-character_s_
+type TerminalVersionEvent struct {
+	Name string
+}
+
+// IsMultiplexer reports whether the reply came from a terminal multiplexer
+// such as tmux or GNU screen rather than the outer terminal. Multiplexers
+// answer queries on behalf of the terminal, so the reported capabilities are
+// the multiplexer's, not the terminal's.
+func (e TerminalVersionEvent) IsMultiplexer() bool {
+	name := strings.ToLower(e.Name)
+	return strings.Contains(name, "tmux") || strings.Contains(name, "screen")
+}
+
+// Version splits the reported name into the terminal name and its version.
+// Terminals report them either as name(version) e.g. "xterm(390)", or
+// separated by a space e.g. "WezTerm 20240203-110809-5046fc22". The version
+// is empty if the reply doesn't have one.
+func (e TerminalVersionEvent) Version() (name, version string) {
+	name = strings.TrimSpace(e.Name)
+	if i := strings.IndexByte(name, '('); i > 0 && strings.HasSuffix(name, ")") {
+		return name[:i], name[i+1 : len(name)-1]
+	}
+	if i := strings.IndexByte(name, ' '); i > 0 {
+		return name[:i], strings.TrimSpace(name[i+1:])
+	}
+	return name, ""
+}
+
+// String implements fmt.Stringer.
+func (e TerminalVersionEvent) String() string {
+	return EventString(e)
+}
@@ -0,0 +1,80 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTerminalVersion(t *testing.T) {
+	cases := []struct {
+		seq   string
+		want  TerminalVersionEvent
+		multi bool
+	}{
+		{"\x1bP>|xterm(390)\x1b\\", TerminalVersionEvent{Name: "xterm(390)"}, false},
+		{"\x1bP>|kitty(0.31.0)\x1b\\", TerminalVersionEvent{Name: "kitty(0.31.0)"}, false},
+		{"\x90>|WezTerm 20240203\x9c", TerminalVersionEvent{Name: "WezTerm 20240203"}, false},
+		{"\x1bP>|tmux 3.4\x1b\\", TerminalVersionEvent{Name: "tmux 3.4"}, true},
+		{"\x1bP>|Screen 4.09.01\x1b\\", TerminalVersionEvent{Name: "Screen 4.09.01"}, true},
+		{"\x1bP>|\x1b\\", TerminalVersionEvent{}, false},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if !reflect.DeepEqual(e, c.want) {
+			t.Errorf("%q: expected %#v, got %#v", c.seq, c.want, e)
+			continue
+		}
+		if got := e.(TerminalVersionEvent).IsMultiplexer(); got != c.multi {
+			t.Errorf("%q: expected multiplexer %v, got %v", c.seq, c.multi, got)
+		}
+	}
+}
+
+func TestTerminalVersion(t *testing.T) {
+	cases := []struct {
+		seq     string
+		name    string
+		version string
+	}{
+		{"\x1bP>|xterm(390)\x1b\\", "xterm", "390"},
+		{"\x1bP>|kitty(0.31.0)\x1b\\", "kitty", "0.31.0"},
+		{"\x1bP>|foot(1.16.2)\x1b\\", "foot", "1.16.2"},
+		{"\x1bP>|WezTerm 20240203-110809-5046fc22\x1b\\", "WezTerm", "20240203-110809-5046fc22"},
+		{"\x1bP>|tmux 3.4\x07", "tmux", "3.4"},
+		{"\x1bP>|foot(1.16.2)\x07", "foot", "1.16.2"},
+		{"\x1bP>|mlterm\x1b\\", "mlterm", ""},
+		{"\x1bP>|\x1b\\", "", ""},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		v, ok := e.(TerminalVersionEvent)
+		if !ok {
+			t.Errorf("%q: expected a TerminalVersionEvent, got %#v", c.seq, e)
+			continue
+		}
+		if name, version := v.Version(); name != c.name || version != c.version {
+			t.Errorf("%q: expected %q %q, got %q %q", c.seq, c.name, c.version, name, version)
+		}
+	}
+
+	// Only XTVERSION replies are version reports.
+	for _, seq := range []string{"\x1bP|xterm\x1b\\", "\x1bP>1|xterm\x1b\\"} {
+		if _, e := ParseSequence([]byte(seq)); reflect.TypeOf(e) == reflect.TypeOf(TerminalVersionEvent{}) {
+			t.Errorf("%q: expected no version report, got %#v", seq, e)
+		}
+	}
+
+	// A BEL terminated reply doesn't swallow the following input.
+	want := []Event{TerminalVersionEvent{Name: "foot(1.16.2)"}, KeyDownEvent{Rune: 'a'}}
+	if got := DecodeString("\x1bP>|foot(1.16.2)\x07a"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -1,11 +1,27 @@
 package input
 
-import "github.com/erikgeiser/coninput"
+import (
+	"unicode/utf16"
+	"unicode/utf8"
 
-func parseWin32InputKeyEvent(vkc coninput.VirtualKeyCode, _ coninput.VirtualKeyCode, r rune, keyDown bool, cks coninput.ControlKeyState, repeatCount uint16) Event {
+	"github.com/erikgeiser/coninput"
+)
+
+func parseWin32InputKeyEvent(vkc coninput.VirtualKeyCode, _ coninput.VirtualKeyCode, r rune, keyDown bool, cks coninput.ControlKeyState, repeatCount uint16, keypadNav bool) (Event, bool) {
 	isCtrl := cks.Contains(coninput.LEFT_CTRL_PRESSED | coninput.RIGHT_CTRL_PRESSED)
 
 	k, ok := vkKeyEvent[vkc]
+	if keypadNav {
+		if kp, isKp := vkKeypadEvent(vkc, cks); isKp {
+			k = kp
+		}
+	}
+	if r == 0 && !isCtrl && isOemKey(vkc) {
+		// The Console API doesn't flag dead keys. A dead key is an OEM key,
+		// e.g. ^ on a French layout, that doesn't translate to a character
+		// on its own.
+		k, ok = KeyDownEvent{Sym: KeyDead}, true
+	}
 	if !ok && isCtrl {
 		k = vkCtrlRune(k, r, vkc)
 	} else if !ok {
@@ -19,30 +35,88 @@ func parseWin32InputKeyEvent(vkc coninput.VirtualKeyCode, _ coninput.VirtualKeyC
 	}
 	if cks.Contains(coninput.SHIFT_PRESSED) {
 		k.Mod |= Shift
+		if oem, ok := vkOemKeys[vkc]; ok && k.Rune == oem.r {
+			// The key is reported with its base rune e.g. ctrl+shift+;
+			// report the shifted rune too like Kitty does.
+			k.ShiftedRune = oem.shifted
+		}
+	}
+
+	// The NUMLOCK_ON, CAPSLOCK_ON, and SCROLLLOCK_ON states mean that the
+	// lock is on, not that the lock key was pressed, keys typed while a lock
+	// is on are reported as usual. Only ignore the lock key records that
+	// don't translate to a key.
+	if isLockKey(vkc) && k.Rune == 0 && k.Sym == 0 {
+		return nil, false
 	}
 
-	// XXX: the following keys when set mean that the key is ON, not that
-	// it was pressed. We should probably ignore them.
-	if cks.Contains(coninput.NUMLOCK_ON|coninput.CAPSLOCK_ON|coninput.SCROLLLOCK_ON) && k.Rune == 0 && k.Sym == 0 {
-		return nil
+	// Windows coalesces held down keys into a single event with a repeat
+	// count. The driver expands these unless FlagCollapseRepeats is set.
+	if repeatCount > 1 {
+		k.IsRepeat = true
+		k.RepeatCount = int(repeatCount)
 	}
 
-	var e Event = KeyDownEvent(k)
-	k.IsRepeat = repeatCount > 1
 	if !keyDown {
-		e = KeyUpEvent(k)
+		return KeyUpEvent(k), true
 	}
 
-	if repeatCount <= 1 {
-		return e
+	return KeyDownEvent(k), true
+}
+
+// decodeConInput converts a batch of unwrapped Windows Console input records
+// to events and applies the parser flags like decode does for the input
+// bytes. A high surrogate is held until its pair arrives, possibly in the next
+// batch. The mouse and surrogate state is only kept when consume is set,
+// peeking the same records again shouldn't change it.
+func (p *Parser) decodeConInput(recs []coninput.EventRecord, ps *coninput.ButtonState, consume bool) []Event {
+	if !consume {
+		state, prevMouse, clicks, ss := *ps, p.prevMouse, p.clicks, p.surrogates
+		defer func() {
+			*ps, p.prevMouse, p.clicks, p.surrogates = state, prevMouse, clicks, ss
+		}()
 	}
 
-	var kevents []Event
-	for i := 0; i < int(repeatCount); i++ {
-		kevents = append(kevents, e)
+	var events []Event
+	for _, rec := range recs {
+		e, ok := parseConInputRecord(rec, ps, p.flags)
+		if !ok {
+			continue
+		}
+		events = p.appendEvent(events, e)
 	}
 
-	return MultiEvent(kevents)
+	return events
+}
+
+// parseConInputRecord converts an unwrapped Windows Console input record to
+// an event. It returns false for records that aren't reported.
+func parseConInputRecord(rec coninput.EventRecord, ps *coninput.ButtonState, flags int) (Event, bool) {
+	switch e := rec.(type) {
+	case coninput.KeyEventRecord:
+		return parseWin32InputKeyEvent(e.VirtualKeyCode, e.VirtualScanCode,
+			e.Char, e.KeyDown, e.ControlKeyState, e.RepeatCount,
+			flags&FlagKeypadNav != 0)
+
+	case coninput.WindowBufferSizeEventRecord:
+		// The size of the screen buffer in cells.
+		return WindowSizeEvent{
+			Width:  int(e.Size.X),
+			Height: int(e.Size.Y),
+		}, true
+	case coninput.MouseEventRecord:
+		return parseWin32MouseEvent(ps, e), true
+	case coninput.FocusEventRecord:
+		// The SetFocus field is documented as reserved, but the console
+		// sets it when the window gains focus.
+		if e.SetFocus {
+			return FocusEvent{}, true
+		}
+		return BlurEvent{}, true
+	case coninput.MenuEventRecord:
+		// ignore
+	}
+	return nil, false
 }
 
 var vkKeyEvent = map[coninput.VirtualKeyCode]KeyDownEvent{
@@ -63,6 +137,7 @@ var vkKeyEvent = map[coninput.VirtualKeyCode]KeyDownEvent{
 	coninput.VK_SELECT:    {Sym: KeySelect},
 	coninput.VK_SNAPSHOT:  {Sym: KeyPrintScreen},
 	coninput.VK_INSERT:    {Sym: KeyInsert},
+	coninput.VK_CLEAR:     {Sym: KeyKpBegin},
 	coninput.VK_LWIN:      {Sym: KeyLeftSuper},
 	coninput.VK_RWIN:      {Sym: KeyRightSuper},
 	coninput.VK_APPS:      {Sym: KeyMenu},
@@ -114,8 +189,84 @@ var vkKeyEvent = map[coninput.VirtualKeyCode]KeyDownEvent{
 	coninput.VK_RCONTROL:  {Sym: KeyRightCtrl},
 	coninput.VK_LMENU:     {Sym: KeyLeftAlt},
 	coninput.VK_RMENU:     {Sym: KeyRightAlt},
-	coninput.VK_OEM_4:     {Rune: '['},
-	// TODO: add more keys
+}
+
+// vkOemKeys maps the OEM virtual key codes to their US layout runes. The
+// Console API reports the rune of the current layout for these keys, except
+// when Ctrl is held, which often reports no rune at all.
+var vkOemKeys = map[coninput.VirtualKeyCode]struct{ r, shifted rune }{
+	coninput.VK_OEM_1:      {';', ':'},
+	coninput.VK_OEM_PLUS:   {'=', '+'},
+	coninput.VK_OEM_COMMA:  {',', '<'},
+	coninput.VK_OEM_MINUS:  {'-', '_'},
+	coninput.VK_OEM_PERIOD: {'.', '>'},
+	coninput.VK_OEM_2:      {'/', '?'},
+	coninput.VK_OEM_3:      {'`', '~'},
+	coninput.VK_OEM_4:      {'[', '{'},
+	coninput.VK_OEM_5:      {'\\', '|'},
+	coninput.VK_OEM_6:      {']', '}'},
+	coninput.VK_OEM_7:      {'\'', '"'},
+}
+
+// vkKeypadEvent returns the keypad key for a navigation or Enter key
+// pressed on the numeric keypad. The dedicated navigation keys and the main
+// Enter key share the same virtual key codes with the keypad keys. Windows
+// sets ENHANCED_KEY for the dedicated navigation keys, and for the keypad
+// Enter key.
+func vkKeypadEvent(vkc coninput.VirtualKeyCode, cks coninput.ControlKeyState) (KeyDownEvent, bool) {
+	enhanced := cks.Contains(coninput.ENHANCED_KEY)
+	if vkc == coninput.VK_RETURN {
+		return KeyDownEvent{Sym: KeyKpEnter}, enhanced
+	}
+	if enhanced {
+		return KeyDownEvent{}, false
+	}
+	switch vkc {
+	case coninput.VK_HOME:
+		return KeyDownEvent{Sym: KeyKpHome}, true
+	case coninput.VK_END:
+		return KeyDownEvent{Sym: KeyKpEnd}, true
+	case coninput.VK_PRIOR:
+		return KeyDownEvent{Sym: KeyKpPgUp}, true
+	case coninput.VK_NEXT:
+		return KeyDownEvent{Sym: KeyKpPgDown}, true
+	case coninput.VK_UP:
+		return KeyDownEvent{Sym: KeyKpUp}, true
+	case coninput.VK_DOWN:
+		return KeyDownEvent{Sym: KeyKpDown}, true
+	case coninput.VK_LEFT:
+		return KeyDownEvent{Sym: KeyKpLeft}, true
+	case coninput.VK_RIGHT:
+		return KeyDownEvent{Sym: KeyKpRight}, true
+	case coninput.VK_INSERT:
+		return KeyDownEvent{Sym: KeyKpInsert}, true
+	case coninput.VK_DELETE:
+		return KeyDownEvent{Sym: KeyKpDelete}, true
+	}
+	return KeyDownEvent{}, false
+}
+
+// isLockKey reports whether the virtual key code is a lock key.
+func isLockKey(vkc coninput.VirtualKeyCode) bool {
+	switch vkc {
+	case coninput.VK_CAPITAL, coninput.VK_NUMLOCK, coninput.VK_SCROLL:
+		return true
+	}
+	return false
+}
+
+// isOemKey reports whether the virtual key code is a layout dependent OEM
+// key i.e. punctuation keys.
+func isOemKey(vkc coninput.VirtualKeyCode) bool {
+	switch vkc {
+	case coninput.VK_OEM_1, coninput.VK_OEM_PLUS, coninput.VK_OEM_COMMA,
+		coninput.VK_OEM_MINUS, coninput.VK_OEM_PERIOD, coninput.VK_OEM_2,
+		coninput.VK_OEM_3, coninput.VK_OEM_4, coninput.VK_OEM_5,
+		coninput.VK_OEM_6, coninput.VK_OEM_7, coninput.VK_OEM_8,
+		coninput.VK_OEM_102:
+		return true
+	}
+	return false
 }
 
 func vkCtrlRune(k KeyDownEvent, r rune, kc coninput.VirtualKeyCode) KeyDownEvent {
@@ -175,17 +326,208 @@ func vkCtrlRune(k KeyDownEvent, r rune, kc coninput.VirtualKeyCode) KeyDownEvent
 	case '\x1a':
 		k.Rune = 'z'
 	case '\x1b':
-		k.Rune = ']'
+		k.Rune = '['
 	case '\x1c':
 		k.Rune = '\\'
+	case '\x1d':
+		k.Rune = ']'
+	case '\x1e':
+		k.Rune = '^'
 	case '\x1f':
 		k.Rune = '_'
 	}
 
-	switch kc {
-	case coninput.VK_OEM_4:
-		k.Rune = '['
+	// Ctrl + <OEM key> combinations without a control character don't
+	// report a rune, use the US layout one. AltGr, reported as Ctrl+Alt,
+	// combinations report the rune of the current layout.
+	if oem, ok := vkOemKeys[kc]; ok && k.Rune == 0 {
+		if r >= ' ' {
+			k.Rune = r
+		} else {
+			k.Rune = oem.r
+		}
 	}
 
 	return k
 }
+
+// surrogates holds the UTF-16 high surrogate key events waiting for their
+// low surrogate pair. The Windows Console reports runes outside the Basic
+// Multilingual Plane, e.g. emojis, as two key records, one per surrogate.
+// Key presses and releases are paired separately since the records of both
+// halves might be interleaved.
+type surrogates struct {
+	down, up key // zero when there's no pending high surrogate
+}
+
+// combine combines a high surrogate key event followed by a low surrogate
+// key event of the same type into a single key event of the decoded rune.
+// The high surrogate event is held until its pair arrives. Unpaired
+// surrogates are reported as U+FFFD. It returns the events to report in
+// order, either of which might be nil.
+func (s *surrogates) combine(e Event) (Event, Event) {
+	var k key
+	var isUp bool
+	switch ev := e.(type) {
+	case KeyDownEvent:
+		k = key(ev)
+	case KeyUpEvent:
+		k, isUp = key(ev), true
+	default:
+		return e, nil
+	}
+
+	pending := &s.down
+	if isUp {
+		pending = &s.up
+	}
+	if pending.Rune == 0 && !utf16.IsSurrogate(k.Rune) {
+		return e, nil
+	}
+	event := func(k key) Event {
+		if isUp {
+			return KeyUpEvent(k)
+		}
+		return KeyDownEvent(k)
+	}
+
+	var unpaired Event
+	if hi := *pending; hi.Rune != 0 {
+		*pending = key{}
+		if isLowSurrogate(k.Rune) {
+			k.Rune = utf16.DecodeRune(hi.Rune, k.Rune)
+			return event(k), nil
+		}
+		hi.Rune = utf8.RuneError
+		unpaired = event(hi)
+	}
+
+	switch {
+	case utf16.IsSurrogate(k.Rune) && !isLowSurrogate(k.Rune):
+		*pending = k
+		return unpaired, nil
+	case isLowSurrogate(k.Rune):
+		// A low surrogate without a high surrogate.
+		k.Rune = utf8.RuneError
+	}
+
+	return unpaired, event(k)
+}
+
+// flush returns the held high surrogates as U+FFFD and resets them.
+func (s *surrogates) flush() []Event {
+	var events []Event
+	if k := s.down; k.Rune != 0 {
+		k.Rune = utf8.RuneError
+		events = append(events, KeyDownEvent(k))
+	}
+	if k := s.up; k.Rune != 0 {
+		k.Rune = utf8.RuneError
+		events = append(events, KeyUpEvent(k))
+	}
+	*s = surrogates{}
+	return events
+}
+
+// isLowSurrogate reports whether r is a UTF-16 low (trailing) surrogate.
+func isLowSurrogate(r rune) bool {
+	return r >= 0xdc00 && r <= 0xdfff
+}
+
+func mouseEventButton(p, s coninput.ButtonState) (button MouseButton, isRelease bool) {
+	btn := p ^ s
+	if btn&s == 0 {
+		isRelease = true
+	}
+
+	if btn == 0 {
+		switch {
+		case s&coninput.FROM_LEFT_1ST_BUTTON_PRESSED > 0:
+			button = MouseButtonLeft
+		case s&coninput.FROM_LEFT_2ND_BUTTON_PRESSED > 0:
+			button = MouseButtonMiddle
+		case s&coninput.RIGHTMOST_BUTTON_PRESSED > 0:
+			button = MouseButtonRight
+		case s&coninput.FROM_LEFT_3RD_BUTTON_PRESSED > 0:
+			button = MouseButtonBackward
+		case s&coninput.FROM_LEFT_4TH_BUTTON_PRESSED > 0:
+			button = MouseButtonForward
+		}
+		return
+	}
+
+	switch {
+	case btn == coninput.FROM_LEFT_1ST_BUTTON_PRESSED: // left button
+		button = MouseButtonLeft
+	case btn == coninput.RIGHTMOST_BUTTON_PRESSED: // right button
+		button = MouseButtonRight
+	case btn == coninput.FROM_LEFT_2ND_BUTTON_PRESSED: // middle button
+		button = MouseButtonMiddle
+	case btn == coninput.FROM_LEFT_3RD_BUTTON_PRESSED: // unknown (possibly mouse backward)
+		button = MouseButtonBackward
+	case btn == coninput.FROM_LEFT_4TH_BUTTON_PRESSED: // unknown (possibly mouse forward)
+		button = MouseButtonForward
+	}
+
+	return
+}
+
+// parseWin32MouseEvent converts a Windows Console mouse event record to a
+// mouse event. The record only holds the state of the buttons, ps is the
+// state of the previous record used to tell which button was pressed or
+// released. It's updated with the state of the record.
+func parseWin32MouseEvent(ps *coninput.ButtonState, e coninput.MouseEventRecord) Event {
+	ev := mouseEvent(*ps, e)
+	switch e.EventFlags {
+	case coninput.MOUSE_WHEELED, coninput.MOUSE_HWHEELED:
+		// The high word of the button state holds the wheel delta, it
+		// doesn't change the state of the buttons.
+	default:
+		*ps = e.ButtonState
+	}
+	return ev
+}
+
+func mouseEvent(p coninput.ButtonState, e coninput.MouseEventRecord) (ev Event) {
+	var mod Mod
+	var isRelease bool
+	if e.ControlKeyState.Contains(coninput.LEFT_ALT_PRESSED | coninput.RIGHT_ALT_PRESSED) {
+		mod |= Alt
+	}
+	if e.ControlKeyState.Contains(coninput.LEFT_CTRL_PRESSED | coninput.RIGHT_CTRL_PRESSED) {
+		mod |= Ctrl
+	}
+	if e.ControlKeyState.Contains(coninput.SHIFT_PRESSED) {
+		mod |= Shift
+	}
+	m := mouse{
+		X:   int(e.MousePositon.X),
+		Y:   int(e.MousePositon.Y),
+		Mod: mod,
+	}
+	switch e.EventFlags {
+	case coninput.CLICK, coninput.DOUBLE_CLICK:
+		m.Button, isRelease = mouseEventButton(p, e.ButtonState)
+	case coninput.MOUSE_WHEELED:
+		if e.WheelDirection > 0 {
+			m.Button = MouseButtonWheelUp
+		} else {
+			m.Button = MouseButtonWheelDown
+		}
+	case coninput.MOUSE_HWHEELED:
+		if e.WheelDirection > 0 {
+			m.Button = MouseButtonWheelRight
+		} else {
+			m.Button = MouseButtonWheelLeft
+		}
+	case coninput.MOUSE_MOVED:
+		m.Button, _ = mouseEventButton(p, e.ButtonState)
+		return MouseMoveEvent(m)
+	}
+
+	if isRelease {
+		return MouseUpEvent(m)
+	}
+
+	return MouseDownEvent(m)
+}
@@ -0,0 +1,405 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/erikgeiser/coninput"
+)
+
+func TestWin32InputRepeats(t *testing.T) {
+	// Win32 input mode Enter key held down with a repeat count of 3
+	// CSI Vk ; Sc ; Uc ; Kd ; Cs ; Rc _
+	in := "\x1b[13;28;13;1;0;3_"
+
+	expanded := KeyDownEvent{Sym: KeyEnter, IsRepeat: true}
+	collapsed := KeyDownEvent{Sym: KeyEnter, IsRepeat: true, RepeatCount: 3}
+
+	if got, want := DecodeString(in), []Event{expanded, expanded, expanded}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got, want := DecodeStringWith(in, FlagCollapseRepeats), []Event{collapsed}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Arrow key release with a repeat count of 2
+	in = "\x1b[38;72;0;0;0;2_"
+	up := KeyUpEvent{Sym: KeyUp, IsRepeat: true}
+	if got, want := DecodeString(in), []Event{up, up}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// A single key press isn't a repeat
+	in = "\x1b[13;28;13;1;0;1_"
+	if got, want := DecodeStringWith(in, FlagCollapseRepeats), []Event{KeyDownEvent{Sym: KeyEnter}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestWin32InputDeadKey(t *testing.T) {
+	// French layout ^ (VK_OEM_6) dead key press and release followed by e,
+	// which produces ê
+	// CSI Vk ; Sc ; Uc ; Kd ; Cs ; Rc _
+	in := "\x1b[221;26;0;1;0;1_" +
+		"\x1b[221;26;0;0;0;1_" +
+		"\x1b[69;18;234;1;0;1_" +
+		"\x1b[69;18;101;0;0;1_"
+
+	want := []Event{
+		KeyDownEvent{Sym: KeyDead},
+		KeyUpEvent{Sym: KeyDead},
+		KeyDownEvent{Rune: 'ê'},
+		KeyUpEvent{Rune: 'e'},
+	}
+	if got := DecodeString(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// An OEM key that produces a character isn't a dead key
+	in = "\x1b[219;26;91;1;0;1_"
+	if got, want := DecodeString(in), []Event{KeyDownEvent{Rune: '['}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestWin32InputKeypadNav(t *testing.T) {
+	// CSI Vk ; Sc ; Uc ; Kd ; Cs ; Rc _
+	// Cs 256 is ENHANCED_KEY, 32 is NUMLOCK_ON, and 8 is LEFT_CTRL_PRESSED.
+	cases := []struct {
+		name   string
+		in     string
+		want   Event
+		keypad Event
+	}{
+		{"home", "\x1b[36;71;0;1;256;1_", KeyDownEvent{Sym: KeyHome}, KeyDownEvent{Sym: KeyHome}},
+		{"keypad home", "\x1b[36;71;0;1;0;1_", KeyDownEvent{Sym: KeyHome}, KeyDownEvent{Sym: KeyKpHome}},
+		{"ctrl+keypad home", "\x1b[36;71;0;1;8;1_", KeyDownEvent{Sym: KeyHome, Mod: Ctrl}, KeyDownEvent{Sym: KeyKpHome, Mod: Ctrl}},
+		{"up", "\x1b[38;72;0;1;256;1_", KeyDownEvent{Sym: KeyUp}, KeyDownEvent{Sym: KeyUp}},
+		{"keypad up", "\x1b[38;72;0;1;0;1_", KeyDownEvent{Sym: KeyUp}, KeyDownEvent{Sym: KeyKpUp}},
+		{"keypad delete", "\x1b[46;83;0;1;0;1_", KeyDownEvent{Sym: KeyDelete}, KeyDownEvent{Sym: KeyKpDelete}},
+		{"keypad 5", "\x1b[12;76;0;1;0;1_", KeyDownEvent{Sym: KeyKpBegin}, KeyDownEvent{Sym: KeyKpBegin}},
+		{"keypad 7 release", "\x1b[36;71;0;0;0;1_", KeyUpEvent{Sym: KeyHome}, KeyUpEvent{Sym: KeyKpHome}},
+		{"numlock keypad 7", "\x1b[103;71;55;1;32;1_", KeyDownEvent{Sym: KeyKp7}, KeyDownEvent{Sym: KeyKp7}},
+		{"numlock keypad 1", "\x1b[97;79;49;1;32;1_", KeyDownEvent{Sym: KeyKp1}, KeyDownEvent{Sym: KeyKp1}},
+		{"enter", "\x1b[13;28;13;1;0;1_", KeyDownEvent{Sym: KeyEnter}, KeyDownEvent{Sym: KeyEnter}},
+		{"keypad enter", "\x1b[13;28;13;1;256;1_", KeyDownEvent{Sym: KeyEnter}, KeyDownEvent{Sym: KeyKpEnter}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got, want := DecodeString(c.in), []Event{c.want}; !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+			if got, want := DecodeStringWith(c.in, FlagKeypadNav), []Event{c.keypad}; !reflect.DeepEqual(got, want) {
+				t.Errorf("keypad: expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestWin32InputLockKeys(t *testing.T) {
+	key := func(vkc coninput.VirtualKeyCode, r rune, cks coninput.ControlKeyState) coninput.KeyEventRecord {
+		return coninput.KeyEventRecord{KeyDown: true, RepeatCount: 1, VirtualKeyCode: vkc, Char: r, ControlKeyState: cks}
+	}
+	locks := coninput.CAPSLOCK_ON | coninput.NUMLOCK_ON | coninput.SCROLLLOCK_ON
+
+	cases := []struct {
+		name string
+		rec  coninput.KeyEventRecord
+		want []Event
+	}{
+		{"caps lock press", key(coninput.VK_CAPITAL, 0, coninput.CAPSLOCK_ON), nil},
+		{"num lock press", key(coninput.VK_NUMLOCK, 0, coninput.NUMLOCK_ON), []Event{KeyDownEvent{Sym: KeyNumLock}}},
+		{"letter with caps lock", key('A', 'A', coninput.CAPSLOCK_ON), []Event{KeyDownEvent{Rune: 'A'}}},
+		{"arrow with all locks", key(coninput.VK_UP, 0, locks), []Event{KeyDownEvent{Sym: KeyUp}}},
+		{"shift with caps lock", key(coninput.VK_SHIFT, 0, coninput.CAPSLOCK_ON|coninput.SHIFT_PRESSED), []Event{KeyDownEvent{Mod: Shift}}},
+		{"unmapped key with caps lock", key(coninput.VK_BROWSER_BACK, 0, coninput.CAPSLOCK_ON), []Event{KeyDownEvent{}}},
+	}
+
+	// Win32 input mode doesn't report ignored keys either.
+	// CSI Vk ; Sc ; Uc ; Kd ; Cs ; Rc _
+	if got := DecodeString("\x1b[20;58;0;1;128;1_a"); !reflect.DeepEqual(got, []Event{KeyDownEvent{Rune: 'a'}}) {
+		t.Errorf("expected the Caps Lock press to be ignored, got %v", got)
+	}
+	if n, e := ParseSequence([]byte("\x1b[20;58;0;1;128;1_")); n != 18 || e != (IgnoredEvent{}) {
+		t.Errorf("expected 18 bytes and an ignored event, got %d and %v", n, e)
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := conInputEvents(0, c.rec); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+
+			// A key is reported the same whether a lock is on or not.
+			if c.want != nil {
+				c.rec.ControlKeyState &^= locks
+				if got := conInputEvents(0, c.rec); !reflect.DeepEqual(got, c.want) {
+					t.Errorf("expected %v without locks, got %v", c.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWin32InputOemKeys(t *testing.T) {
+	// CSI Vk ; Sc ; Uc ; Kd ; Cs ; Rc _
+	// Cs 16 is SHIFT_PRESSED, 8 is LEFT_CTRL_PRESSED, and 10 is
+	// LEFT_CTRL_PRESSED | LEFT_ALT_PRESSED i.e. AltGr.
+	cases := []struct {
+		name string
+		in   string
+		want Event
+	}{
+		{"semicolon", "\x1b[186;39;59;1;0;1_", KeyDownEvent{Rune: ';'}},
+		{"colon", "\x1b[186;39;58;1;16;1_", KeyDownEvent{Rune: ':', Mod: Shift}},
+		{"equal", "\x1b[187;13;61;1;0;1_", KeyDownEvent{Rune: '='}},
+		{"comma", "\x1b[188;51;44;1;0;1_", KeyDownEvent{Rune: ','}},
+		{"minus", "\x1b[189;12;45;1;0;1_", KeyDownEvent{Rune: '-'}},
+		{"period", "\x1b[190;52;46;1;0;1_", KeyDownEvent{Rune: '.'}},
+		{"slash", "\x1b[191;53;47;1;0;1_", KeyDownEvent{Rune: '/'}},
+		{"backtick", "\x1b[192;41;96;1;0;1_", KeyDownEvent{Rune: '`'}},
+		{"left bracket", "\x1b[219;26;91;1;0;1_", KeyDownEvent{Rune: '['}},
+		{"left brace", "\x1b[219;26;123;1;16;1_", KeyDownEvent{Rune: '{', Mod: Shift}},
+		{"backslash", "\x1b[220;43;92;1;0;1_", KeyDownEvent{Rune: '\\'}},
+		{"right bracket", "\x1b[221;27;93;1;0;1_", KeyDownEvent{Rune: ']'}},
+		{"quote", "\x1b[222;40;39;1;0;1_", KeyDownEvent{Rune: '\''}},
+		{"ctrl+semicolon", "\x1b[186;39;0;1;8;1_", KeyDownEvent{Rune: ';', Mod: Ctrl}},
+		{"ctrl+shift+semicolon", "\x1b[186;39;0;1;24;1_", KeyDownEvent{Rune: ';', ShiftedRune: ':', Mod: Ctrl | Shift}},
+		{"ctrl+equal", "\x1b[187;13;0;1;8;1_", KeyDownEvent{Rune: '=', Mod: Ctrl}},
+		{"ctrl+comma", "\x1b[188;51;0;1;8;1_", KeyDownEvent{Rune: ',', Mod: Ctrl}},
+		{"ctrl+period", "\x1b[190;52;0;1;8;1_", KeyDownEvent{Rune: '.', Mod: Ctrl}},
+		{"ctrl+slash", "\x1b[191;53;0;1;8;1_", KeyDownEvent{Rune: '/', Mod: Ctrl}},
+		{"ctrl+backtick", "\x1b[192;41;0;1;8;1_", KeyDownEvent{Rune: '`', Mod: Ctrl}},
+		{"ctrl+quote", "\x1b[222;40;0;1;8;1_", KeyDownEvent{Rune: '\'', Mod: Ctrl}},
+		{"ctrl+left bracket", "\x1b[219;26;27;1;8;1_", KeyDownEvent{Rune: '[', Mod: Ctrl}},
+		{"ctrl+backslash", "\x1b[220;43;28;1;8;1_", KeyDownEvent{Rune: '\\', Mod: Ctrl}},
+		{"ctrl+right bracket", "\x1b[221;27;29;1;8;1_", KeyDownEvent{Rune: ']', Mod: Ctrl}},
+		{"ctrl+minus", "\x1b[189;12;31;1;8;1_", KeyDownEvent{Rune: '_', Mod: Ctrl}},
+		{"altgr German backslash", "\x1b[219;12;92;1;10;1_", KeyDownEvent{Rune: '\\', Mod: Ctrl | Alt}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := DecodeString(c.in); !reflect.DeepEqual(got, []Event{c.want}) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestWin32InputSurrogates(t *testing.T) {
+	// CSI Vk ; Sc ; Uc ; Kd ; Cs ; Rc _
+	// 😀 (U+1F600) is reported as the surrogate pair U+D83D U+DE00 with a
+	// zero virtual key code.
+	const (
+		hiDown = "\x1b[0;0;55357;1;0;1_"
+		loDown = "\x1b[0;0;56832;1;0;1_"
+		hiUp   = "\x1b[0;0;55357;0;0;1_"
+		loUp   = "\x1b[0;0;56832;0;0;1_"
+	)
+
+	cases := []struct {
+		name   string
+		chunks []string
+		want   []Event
+	}{
+		{"pair", []string{hiDown + loDown}, []Event{KeyDownEvent{Rune: '😀'}}},
+		{"pair with releases", []string{hiDown + loDown + hiUp + loUp}, []Event{KeyDownEvent{Rune: '😀'}, KeyUpEvent{Rune: '😀'}}},
+		{"interleaved releases", []string{hiDown + hiUp + loDown + loUp}, []Event{KeyDownEvent{Rune: '😀'}, KeyUpEvent{Rune: '😀'}}},
+		{"split across reads", []string{hiDown, loDown}, []Event{KeyDownEvent{Rune: '😀'}}},
+		{"lone high", []string{hiDown + "a"}, []Event{KeyDownEvent{Rune: '�'}, KeyDownEvent{Rune: 'a'}}},
+		{"lone low", []string{loDown + "a"}, []Event{KeyDownEvent{Rune: '�'}, KeyDownEvent{Rune: 'a'}}},
+		{"two highs", []string{hiDown + hiDown + loDown}, []Event{KeyDownEvent{Rune: '�'}, KeyDownEvent{Rune: '😀'}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := newDriver("", 0)
+			var got []Event
+			for _, chunk := range c.chunks {
+				got = append(got, d.parser.decode([]byte(chunk))...)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestWin32MouseEvent(t *testing.T) {
+	const (
+		left  = coninput.FROM_LEFT_1ST_BUTTON_PRESSED
+		right = coninput.RIGHTMOST_BUTTON_PRESSED
+	)
+	pos := coninput.Coord{X: 10, Y: 5}
+	wheel := func(delta int16) coninput.ButtonState {
+		return coninput.ButtonState(uint32(uint16(delta)) << 16)
+	}
+
+	// Records in order, each one is parsed with the button state of the
+	// previous one.
+	cases := []struct {
+		name string
+		rec  coninput.MouseEventRecord
+		want Event
+	}{
+		{
+			"left press",
+			coninput.MouseEventRecord{MousePositon: pos, ButtonState: left, EventFlags: coninput.CLICK},
+			MouseDownEvent{X: 10, Y: 5, Button: MouseButtonLeft},
+		},
+		{
+			"drag",
+			coninput.MouseEventRecord{MousePositon: coninput.Coord{X: 11, Y: 5}, ButtonState: left, EventFlags: coninput.MOUSE_MOVED},
+			MouseMoveEvent{X: 11, Y: 5, Button: MouseButtonLeft},
+		},
+		{
+			"left release",
+			coninput.MouseEventRecord{MousePositon: pos, EventFlags: coninput.CLICK},
+			MouseUpEvent{X: 10, Y: 5, Button: MouseButtonLeft},
+		},
+		{
+			"double click",
+			coninput.MouseEventRecord{MousePositon: pos, ButtonState: left, EventFlags: coninput.DOUBLE_CLICK},
+			MouseDownEvent{X: 10, Y: 5, Button: MouseButtonLeft},
+		},
+		{
+			"release after double click",
+			coninput.MouseEventRecord{MousePositon: pos, EventFlags: coninput.CLICK},
+			MouseUpEvent{X: 10, Y: 5, Button: MouseButtonLeft},
+		},
+		{
+			"ctrl+shift move",
+			coninput.MouseEventRecord{MousePositon: pos, ControlKeyState: coninput.LEFT_CTRL_PRESSED | coninput.SHIFT_PRESSED, EventFlags: coninput.MOUSE_MOVED},
+			MouseMoveEvent{X: 10, Y: 5, Mod: Ctrl | Shift},
+		},
+		{
+			"wheel up",
+			coninput.MouseEventRecord{MousePositon: pos, ButtonState: wheel(120), EventFlags: coninput.MOUSE_WHEELED, WheelDirection: 1},
+			MouseDownEvent{X: 10, Y: 5, Button: MouseButtonWheelUp},
+		},
+		{
+			"alt+wheel down",
+			coninput.MouseEventRecord{MousePositon: pos, ButtonState: wheel(-120), ControlKeyState: coninput.RIGHT_ALT_PRESSED, EventFlags: coninput.MOUSE_WHEELED, WheelDirection: -1},
+			MouseDownEvent{X: 10, Y: 5, Button: MouseButtonWheelDown, Mod: Alt},
+		},
+		{
+			"wheel right",
+			coninput.MouseEventRecord{MousePositon: pos, ButtonState: wheel(120), EventFlags: coninput.MOUSE_HWHEELED, WheelDirection: 1},
+			MouseDownEvent{X: 10, Y: 5, Button: MouseButtonWheelRight},
+		},
+		{
+			"wheel left",
+			coninput.MouseEventRecord{MousePositon: pos, ButtonState: wheel(-120), EventFlags: coninput.MOUSE_HWHEELED, WheelDirection: -1},
+			MouseDownEvent{X: 10, Y: 5, Button: MouseButtonWheelLeft},
+		},
+		{
+			// The wheel delta isn't part of the button state.
+			"right press after wheel",
+			coninput.MouseEventRecord{MousePositon: pos, ButtonState: right, EventFlags: coninput.CLICK},
+			MouseDownEvent{X: 10, Y: 5, Button: MouseButtonRight},
+		},
+		{
+			"right release",
+			coninput.MouseEventRecord{MousePositon: pos, EventFlags: coninput.CLICK},
+			MouseUpEvent{X: 10, Y: 5, Button: MouseButtonRight},
+		},
+	}
+
+	var ps coninput.ButtonState
+	for _, c := range cases {
+		if got := parseWin32MouseEvent(&ps, c.rec); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+// conInputEvents converts the Windows Console input records to events the
+// way the Windows driver does.
+func conInputEvents(flags int, recs ...coninput.EventRecord) []Event {
+	var ps coninput.ButtonState
+	return newParser("", flags).decodeConInput(recs, &ps, true)
+}
+
+func TestConInputMouseFlags(t *testing.T) {
+	left := coninput.FROM_LEFT_1ST_BUTTON_PRESSED
+	recs := []coninput.EventRecord{
+		coninput.MouseEventRecord{MousePositon: coninput.Coord{X: 3, Y: 4}, ButtonState: left, EventFlags: coninput.CLICK},
+		coninput.MouseEventRecord{MousePositon: coninput.Coord{X: 5, Y: 6}, ButtonState: left, EventFlags: coninput.MOUSE_MOVED},
+		coninput.MouseEventRecord{MousePositon: coninput.Coord{X: 5, Y: 6}, EventFlags: coninput.CLICK},
+		coninput.MouseEventRecord{MousePositon: coninput.Coord{X: 5, Y: 6}, ButtonState: left, EventFlags: coninput.DOUBLE_CLICK},
+	}
+
+	// The same as the SGR mouse path i.e. "\x1b[<0;4;5M\x1b[<32;6;7M\x1b[<0;6;7m\x1b[<0;6;7M".
+	want := []Event{
+		MouseDownEvent{X: 3, Y: 4, Button: MouseButtonLeft, Clicks: 1},
+		MouseMoveEvent{X: 5, Y: 6, DX: 2, DY: 2, Button: MouseButtonLeft},
+		MouseUpEvent{X: 5, Y: 6, Button: MouseButtonLeft},
+		MouseDownEvent{X: 5, Y: 6, Button: MouseButtonLeft, Clicks: 1},
+	}
+	flags := FlagMouseDelta | FlagMouseClicks
+	if got := conInputEvents(flags, recs...); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := DecodeStringWith("\x1b[<0;4;5M\x1b[<32;6;7M\x1b[<0;6;7m\x1b[<0;6;7M", flags); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the SGR mouse events %v, got %v", want, got)
+	}
+
+	// Peeking doesn't change the mouse state.
+	p := newParser("", flags)
+	var ps coninput.ButtonState
+	peeked := p.decodeConInput(recs, &ps, false)
+	if got := p.decodeConInput(recs, &ps, true); !reflect.DeepEqual(got, peeked) || !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v after peeking %v", want, got, peeked)
+	}
+}
+
+func TestConInputSurrogatesAcrossBatches(t *testing.T) {
+	hi := coninput.KeyEventRecord{KeyDown: true, RepeatCount: 1, Char: 0xd83d}
+	lo := coninput.KeyEventRecord{KeyDown: true, RepeatCount: 1, Char: 0xde00}
+
+	p := newParser("", 0)
+	var ps coninput.ButtonState
+	if got := p.decodeConInput([]coninput.EventRecord{hi}, &ps, true); len(got) != 0 {
+		t.Fatalf("expected the high surrogate to be held, got %v", got)
+	}
+
+	// Peeking the next batch doesn't consume the held surrogate.
+	want := []Event{KeyDownEvent{Rune: '😀'}}
+	if got := p.decodeConInput([]coninput.EventRecord{lo}, &ps, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected peeked %v, got %v", want, got)
+	}
+	if got := p.decodeConInput([]coninput.EventRecord{lo}, &ps, true); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// A held high surrogate is reported as U+FFFD on flush.
+	p.decodeConInput([]coninput.EventRecord{hi}, &ps, true)
+	want = []Event{KeyDownEvent{Rune: utf8.RuneError}}
+	if got := p.Flush(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestConInputRecords(t *testing.T) {
+	got := conInputEvents(0,
+		coninput.FocusEventRecord{SetFocus: true},
+		coninput.WindowBufferSizeEventRecord{Size: coninput.Coord{X: 120, Y: 30}},
+		coninput.KeyEventRecord{KeyDown: true, RepeatCount: 1, VirtualKeyCode: 'A', Char: 'a'},
+		coninput.MouseEventRecord{MousePositon: coninput.Coord{X: 3, Y: 4}, ButtonState: coninput.FROM_LEFT_1ST_BUTTON_PRESSED},
+		coninput.MenuEventRecord{CommandID: 1},
+		coninput.FocusEventRecord{SetFocus: false},
+	)
+	want := []Event{
+		FocusEvent{},
+		WindowSizeEvent{Width: 120, Height: 30},
+		KeyDownEvent{Rune: 'a'},
+		MouseDownEvent{X: 3, Y: 4, Button: MouseButtonLeft},
+		BlurEvent{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -1,31 +1,130 @@
 package input
 
 import (
+	"bytes"
+	"unicode/utf8"
+
 	"github.com/charmbracelet/x/exp/term/ansi"
 )
 
+// xtermModMask is the mask of modifier bits an XTerm modifier parameter can
+// report. XTerm only uses the first 4 bits, extended protocols like Kitty's
+// use up to 8, which map to Shift through NumLock.
+const xtermModMask = 0xff
+
+// xtermModMax is the largest XTerm modifier parameter terminals send for keys
+// i.e. Shift, Alt, Ctrl, and Meta.
+const xtermModMax = 16
+
+// parseXTermModifier converts an XTerm modifier parameter to a Mod. XTerm
+// modifier parameters are offset by 1 i.e. 2 is Shift, 3 is Alt, and 5 is
+// Ctrl. Zero and one mean no modifiers. Out of range values, i.e. with bits
+// outside of xtermModMask, mean no modifiers too so they don't corrupt the
+// Mod bits.
+//
+// See https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-PC-Style-Function-Keys
+func parseXTermModifier(n uint) Mod {
+	if n <= 1 || n-1 > xtermModMask {
+		return 0
+	}
+	return Mod(n - 1)
+}
+
+// encodeXTermModifier converts a Mod to an XTerm modifier parameter. It's the
+// inverse of parseXTermModifier.
+func encodeXTermModifier(m Mod) uint {
+	return uint(m&xtermModMask) + 1
+}
+
+// parseXTermModifyOtherKeys parses an XTerm modifyOtherKeys key i.e.
+// CSI 27 ; <modifier> ; <code> ~. The code is the character the key
+// produces, XTerm reports ctrl+shift+a as the shifted 'A' with the Shift
+// modifier. ASCII letters are reported as lowercase like Kitty does, so
+// ctrl+shift+a is the same key regardless of the letter case the terminal
+// reports. Other characters are reported as is since the unshifted key
+// depends on the keyboard layout.
+//
+// An empty or zero modifier means no modifiers. It returns nil if the params
+// are malformed or the code isn't a valid rune.
 func parseXTermModifyOtherKeys(params [][]uint) Event {
-	// XTerm modify other keys starts with ESC [ 27 ; <modifier> ; <code> ~
-	mod := Mod(params[1][0] - 1)
+	if len(params) != 3 || len(params[1]) == 0 || len(params[2]) == 0 {
+		return nil
+	}
+
+	mod := parseXTermModifier(params[1][0])
 	r := rune(params[2][0])
+	if params[2][0] > utf8.MaxRune || !utf8.ValidRune(r) {
+		return nil
+	}
 	k, ok := modifyOtherKeys[int(r)]
 	if ok {
 		k.Mod = mod
 		return k
 	}
 
+	if r >= 'A' && r <= 'Z' {
+		r += 'a' - 'A'
+	}
+
 	return KeyDownEvent{
 		Mod:  mod,
 		Rune: r,
 	}
 }
 
+// isModifyOtherKeys reports whether the sequence is an XTerm
+// modifyOtherKeys key i.e. CSI 27 ; <modifier> ; <code> ~.
+func isModifyOtherKeys(seq []byte) bool {
+	switch {
+	case bytes.HasPrefix(seq, []byte("\x1b[")):
+		seq = seq[2:]
+	case len(seq) > 0 && seq[0] == ansi.CSI:
+		seq = seq[1:]
+	default:
+		return false
+	}
+	return bytes.HasPrefix(seq, []byte("27;")) && seq[len(seq)-1] == '~'
+}
+
+// SetModifyOtherKeys sets the XTerm modifyOtherKeys mode the terminal is in.
+// The parser also keeps track of the mode reported by the terminal in
+// response to a query i.e. a ModifyOtherKeysEvent.
+//
+// In mode 2, XTerm reports keys that would otherwise be plain text, and the
+// code of a shifted letter is the unshifted letter. The parser re-applies
+// Shift to these: shift+a is reported as the text 'A' like in mode 1, and
+// letters with other modifiers report the shifted letter in ShiftedRune
+// e.g. ctrl+shift+a is 'a' with ShiftedRune 'A'.
+func (p *Parser) SetModifyOtherKeys(mode ModifyOtherKeysEvent) {
+	p.modifyOtherKeys = mode
+}
+
+// modifyOtherKeysKey adjusts a modifyOtherKeys key to the active mode.
+func (p *Parser) modifyOtherKeysKey(k KeyDownEvent) KeyDownEvent {
+	if p.modifyOtherKeys != 2 || !k.Mod.IsShift() || k.Sym != KeyNone || k.Rune < 'a' || k.Rune > 'z' {
+		return k
+	}
+
+	shifted := k.Rune - 'a' + 'A'
+	if k.Mod == Shift {
+		// The text the key would produce in mode 1.
+		return KeyDownEvent{Rune: shifted}
+	}
+	k.ShiftedRune = shifted
+	return k
+}
+
 // CSI 27 ; <modifier> ; <code> ~ keys defined in XTerm modifyOtherKeys
+//
+// Only the control characters of named keys are reported as key symbols.
+// XTerm reports Ctrl + <letter> as the letter e.g. ctrl+j is 106 and not LF.
+// Every other code is a rune.
 var modifyOtherKeys = map[int]KeyDownEvent{
 	ansi.BS:  {Sym: KeyBackspace},
 	ansi.HT:  {Sym: KeyTab},
 	ansi.CR:  {Sym: KeyEnter},
 	ansi.ESC: {Sym: KeyEscape},
+	ansi.SP:  {Sym: KeySpace, Rune: ' '},
 	ansi.DEL: {Sym: KeyBackspace},
 }
 
@@ -41,13 +140,5 @@ type ModifyOtherKeysEvent uint8
 
 // String implements fmt.Stringer.
 func (m ModifyOtherKeysEvent) String() string {
-	switch m {
-	case 0:
-		return "ModifyOtherKeys Disable"
-	case 1:
-		return "ModifyOtherKeys Mode 1"
-	case 2:
-		return "ModifyOtherKeys Mode 2"
-	}
-	return "Unknown ModifyOtherKeys"
+	return EventString(m)
 }
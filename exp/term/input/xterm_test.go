@@ -0,0 +1,225 @@
+package input
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestXTermModifierRoundTrip(t *testing.T) {
+	mods := []Mod{Shift, Alt, Ctrl, Meta}
+
+	// All 15 combinations of Shift, Alt, Ctrl, and Meta.
+	for i := 1; i < 1<<len(mods); i++ {
+		var m Mod
+		for j, mod := range mods {
+			if i&(1<<j) != 0 {
+				m |= mod
+			}
+		}
+
+		n := encodeXTermModifier(m)
+		if n < 2 || n > 16 {
+			t.Errorf("%v: expected parameter in range 2-16, got %d", m, n)
+		}
+		if got := parseXTermModifier(n); got != m {
+			t.Errorf("%d: expected %v, got %v", n, m, got)
+		}
+
+		// The table and the parser must agree.
+		seq := fmt.Sprintf("\x1b[1;%dA", n)
+		want := KeyDownEvent{Sym: KeyUp, Mod: m}
+		if _, e := ParseSequence([]byte(seq)); e != want {
+			t.Errorf("%q: expected parser %v, got %v", seq, want, e)
+		}
+		if got := DecodeString(seq); len(got) != 1 || got[0] != want {
+			t.Errorf("%q: expected table %v, got %v", seq, want, got)
+		}
+	}
+
+	for _, n := range []uint{0, 1} {
+		if got := parseXTermModifier(n); got != 0 {
+			t.Errorf("%d: expected no modifiers, got %v", n, got)
+		}
+	}
+}
+
+func TestXTermModifierOutOfRange(t *testing.T) {
+	cases := []struct {
+		n    uint
+		want Mod
+	}{
+		{256, Shift | Alt | Ctrl | Meta | Hyper | Super | CapsLock | NumLock},
+		{257, 0},
+		{258, 0},
+		{1 << 16, 0},
+		{1<<16 + 1, 0},
+		{1<<16 + 6, 0},
+	}
+	for _, c := range cases {
+		if got := parseXTermModifier(c.n); got != c.want {
+			t.Errorf("%d: expected %v, got %v", c.n, c.want, got)
+		}
+		if got := parseXTermModifier(c.n); got&ScrollLock != 0 || got > xtermModMask {
+			t.Errorf("%d: unexpected modifier bits %b", c.n, got)
+		}
+	}
+
+	// modifyOtherKeys with an out of range modifier
+	seq := "\x1b[27;65542;97~"
+	want := KeyDownEvent{Rune: 'a'}
+	if _, e := ParseSequence([]byte(seq)); e != want {
+		t.Errorf("%q: expected %v, got %v", seq, want, e)
+	}
+}
+
+func TestParseXTermModifyOtherKeys(t *testing.T) {
+	cases := []struct {
+		seq  string
+		want KeyDownEvent
+	}{
+		{"\x1b[27;5;97~", KeyDownEvent{Rune: 'a', Mod: Ctrl}},
+		{"\x1b[27;6;97~", KeyDownEvent{Rune: 'a', Mod: Ctrl | Shift}},
+		{"\x1b[27;6;65~", KeyDownEvent{Rune: 'a', Mod: Ctrl | Shift}},
+		{"\x1b[27;5;65~", KeyDownEvent{Rune: 'a', Mod: Ctrl}},
+		{"\x1b[27;7;90~", KeyDownEvent{Rune: 'z', Mod: Ctrl | Alt}},
+		{"\x1b[27;5;49~", KeyDownEvent{Rune: '1', Mod: Ctrl}},
+		{"\x1b[27;6;33~", KeyDownEvent{Rune: '!', Mod: Ctrl | Shift}},
+		{"\x1b[27;5;59~", KeyDownEvent{Rune: ';', Mod: Ctrl}},
+		{"\x1b[27;6;201~", KeyDownEvent{Rune: 'É', Mod: Ctrl | Shift}},
+		{"\x1b[27;5;13~", KeyDownEvent{Sym: KeyEnter, Mod: Ctrl}},
+		{"\x1b[27;2;9~", KeyDownEvent{Sym: KeyTab, Mod: Shift}},
+		{"\x1b[27;5;8~", KeyDownEvent{Sym: KeyBackspace, Mod: Ctrl}},
+		{"\x1b[27;3;127~", KeyDownEvent{Sym: KeyBackspace, Mod: Alt}},
+		{"\x1b[27;5;27~", KeyDownEvent{Sym: KeyEscape, Mod: Ctrl}},
+		{"\x1b[27;5;32~", KeyDownEvent{Sym: KeySpace, Rune: ' ', Mod: Ctrl}},
+	}
+
+	for _, c := range cases {
+		n, e := ParseSequence([]byte(c.seq))
+		if n != len(c.seq) {
+			t.Errorf("%q: expected %d bytes, got %d", c.seq, len(c.seq), n)
+		}
+		if e != c.want {
+			t.Errorf("%q: expected %v, got %v", c.seq, c.want, e)
+		}
+	}
+
+	// Both letter cases of ctrl+shift+a are the same key.
+	if a, b := DecodeString("\x1b[27;6;97~"), DecodeString("\x1b[27;6;65~"); !reflect.DeepEqual(a, b) {
+		t.Errorf("expected %v and %v to be the same", a, b)
+	}
+}
+
+func TestParseXTermModifyOtherKeysMalformed(t *testing.T) {
+	for _, params := range [][][]uint{
+		nil,
+		{},
+		{{27}},
+		{{27}, {5}},
+		{{27}, {}, {97}},
+		{{27}, {5}, {}},
+		{{27}, {5}, {97}, {1}},
+		{{27}, {5}, {0xd800}},
+		{{27}, {5}, {utf8.MaxRune + 1}},
+	} {
+		if e := parseXTermModifyOtherKeys(params); e != nil {
+			t.Errorf("%v: expected nil, got %v", params, e)
+		}
+	}
+
+	// Zero and empty modifiers mean no modifiers.
+	for _, seq := range []string{"\x1b[27;0;97~", "\x1b[27;;97~", "\x1b[27;1;97~"} {
+		if _, e := ParseSequence([]byte(seq)); e != (KeyDownEvent{Rune: 'a'}) {
+			t.Errorf("%q: expected a, got %v", seq, e)
+		}
+	}
+
+	// Malformed sequences are unknown.
+	for _, seq := range []string{"\x1b[27;5~", "\x1b[27;5;97;1~", "\x1b[27;5;55296~"} {
+		if _, e := ParseSequence([]byte(seq)); !reflect.DeepEqual(e, UnknownCsiEvent(seq)) {
+			t.Errorf("%q: expected an unknown sequence, got %v", seq, e)
+		}
+	}
+}
+
+func FuzzParseXTermModifyOtherKeys(f *testing.F) {
+	f.Add([]byte{5, ';', 97})
+	f.Add([]byte{';', ';'})
+	f.Add([]byte{0xff, 0xff, ':', 0xff})
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Build the params from the data, ';' separates params and ':'
+		// sub-params, any other byte is a value.
+		params := [][]uint{{27}, {}}
+		for _, b := range data {
+			last := len(params) - 1
+			switch b {
+			case ';':
+				params = append(params, []uint{})
+			case ':':
+				params[last] = append(params[last], 0)
+			default:
+				params[last] = append(params[last], uint(b)<<(b%24))
+			}
+		}
+
+		// It must not panic.
+		parseXTermModifyOtherKeys(params)
+		ParseSequence(append([]byte("\x1b[27;"), append(data, '~')...))
+	})
+}
+
+func TestModifyOtherKeysModes(t *testing.T) {
+	cases := []struct {
+		seq   string
+		mode1 KeyDownEvent
+		mode2 KeyDownEvent
+	}{
+		{"\x1b[27;2;97~", KeyDownEvent{Rune: 'a', Mod: Shift}, KeyDownEvent{Rune: 'A'}},
+		{"\x1b[27;2;65~", KeyDownEvent{Rune: 'a', Mod: Shift}, KeyDownEvent{Rune: 'A'}},
+		{"\x1b[27;6;97~", KeyDownEvent{Rune: 'a', Mod: Ctrl | Shift}, KeyDownEvent{Rune: 'a', ShiftedRune: 'A', Mod: Ctrl | Shift}},
+		{"\x1b[27;4;122~", KeyDownEvent{Rune: 'z', Mod: Alt | Shift}, KeyDownEvent{Rune: 'z', ShiftedRune: 'Z', Mod: Alt | Shift}},
+
+		// Keys without Shift and non-letters are the same in both modes.
+		{"\x1b[27;5;97~", KeyDownEvent{Rune: 'a', Mod: Ctrl}, KeyDownEvent{Rune: 'a', Mod: Ctrl}},
+		{"\x1b[27;2;33~", KeyDownEvent{Rune: '!', Mod: Shift}, KeyDownEvent{Rune: '!', Mod: Shift}},
+		{"\x1b[27;2;9~", KeyDownEvent{Sym: KeyTab, Mod: Shift}, KeyDownEvent{Sym: KeyTab, Mod: Shift}},
+	}
+
+	for _, c := range cases {
+		for _, mode := range []ModifyOtherKeysEvent{0, 1, 2} {
+			want := c.mode1
+			if mode == 2 {
+				want = c.mode2
+			}
+
+			p, err := NewParser(WithModifyOtherKeys(mode))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := parseChunks(p, c.seq); !reflect.DeepEqual(got, []Event{want}) {
+				t.Errorf("%q: mode %d: expected %v, got %v", c.seq, mode, want, got)
+			}
+
+			// The mode reported by the terminal is tracked.
+			p = newParser("", 0)
+			reply := fmt.Sprintf("\x1b[>4;%dm", mode)
+			if got := parseChunks(p, reply, c.seq); !reflect.DeepEqual(got, []Event{mode, want}) {
+				t.Errorf("%q: reported mode %d: expected %v, got %v", c.seq, mode, want, got)
+			}
+		}
+	}
+
+	// Shift is re-applied before normalization so both modes report the same
+	// normalized keys.
+	for _, mode := range []ModifyOtherKeysEvent{1, 2} {
+		p, _ := NewParser(WithModifyOtherKeys(mode), WithFlags(FlagNormalizeShift))
+		want := []Event{KeyDownEvent{Rune: 'A'}, KeyDownEvent{Rune: 'A', Mod: Ctrl}}
+		if got := parseChunks(p, "\x1b[27;2;97~\x1b[27;6;97~"); !reflect.DeepEqual(got, want) {
+			t.Errorf("mode %d: expected %v, got %v", mode, want, got)
+		}
+	}
+}